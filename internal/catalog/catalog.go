@@ -0,0 +1,316 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Table types as reported by the Hive metastore.
+const (
+	ManagedTable  = "MANAGED_TABLE"
+	ExternalTable = "EXTERNAL_TABLE"
+	VirtualView   = "VIRTUAL_VIEW"
+
+	MaterializedView = "MATERIALIZED_VIEW"
+)
+
+// Column is a column as declared in Hive DDL.
+type Column struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // Hive type, e.g. "string", "array<int>"
+	Comment string `json:"comment,omitempty"`
+}
+
+// Storage holds the ROW FORMAT / STORED AS clauses of a table.
+type Storage struct {
+	Format          string            `json:"format,omitempty"` // TEXTFILE, PARQUET, ORC, JSONFILE, ...
+	SerDe           string            `json:"serde,omitempty"`
+	SerDeProperties map[string]string `json:"serde_properties,omitempty"`
+	InputFormat     string            `json:"input_format,omitempty"`
+	OutputFormat    string            `json:"output_format,omitempty"`
+	FieldDelim      string            `json:"field_delim,omitempty"`
+	EscapeDelim     string            `json:"escape_delim,omitempty"`
+	CollectionDelim string            `json:"collection_delim,omitempty"`
+	MapKeyDelim     string            `json:"map_key_delim,omitempty"`
+	LineDelim       string            `json:"line_delim,omitempty"`
+	NullFormat      string            `json:"null_format,omitempty"`
+}
+
+// Buckets holds a CLUSTERED BY ... INTO n BUCKETS clause.
+type Buckets struct {
+	Columns []string `json:"columns,omitempty"`
+	SortBy  []string `json:"sort_by,omitempty"`
+	Count   int      `json:"count,omitempty"`
+}
+
+// Partition is a registered partition of a table.
+type Partition struct {
+	Values   []string `json:"values"`             // one per partition key, in key order
+	Location string   `json:"location,omitempty"` // directory holding the partition's files, if file-backed
+}
+
+// Table holds the Hive metadata of a table that DuckDB itself cannot represent.
+type Table struct {
+	Database      string            `json:"database"`
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	Columns       []Column          `json:"columns,omitempty"`
+	PartitionKeys []Column          `json:"partition_keys,omitempty"`
+	Comment       string            `json:"comment,omitempty"`
+	Storage       Storage           `json:"storage"`
+	Location      string            `json:"location,omitempty"`
+	Properties    map[string]string `json:"properties,omitempty"`
+	Buckets       *Buckets          `json:"buckets,omitempty"`
+	Partitions    []Partition       `json:"partitions,omitempty"`
+	CreateTime    time.Time         `json:"create_time"`
+
+	// WarehouseFiles marks a managed table whose data is kept as Parquet
+	// files in its warehouse directory rather than in DuckDB.
+	WarehouseFiles bool `json:"warehouse_files,omitempty"`
+
+	// Materialized is the definition of a materialized view.
+	Materialized *Materialization `json:"materialized,omitempty"`
+}
+
+// Materialization is the stored query of a materialized view, which is a
+// DuckDB table holding the query's result as of the last rebuild.
+type Materialization struct {
+	Query           string    `json:"query"`
+	CurrentDatabase string    `json:"current_database"` // resolves the query's unqualified names
+	RebuildTime     time.Time `json:"rebuild_time"`
+	RewriteEnabled  bool      `json:"rewrite_enabled"`
+}
+
+// FileBacked reports whether the table's data is read from files at its
+// location rather than stored in DuckDB.
+func (t *Table) FileBacked() bool {
+	return t.WarehouseFiles || (t.Type == ExternalTable && t.Location != "")
+}
+
+// PartitionName renders partition values in Hive's ds=x/hr=y form.
+func (t *Table) PartitionName(values []string) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = t.PartitionKeys[i].Name + "=" + v
+	}
+	return strings.Join(parts, "/")
+}
+
+// FindPartition returns the index of the partition with the given values, or -1.
+func (t *Table) FindPartition(values []string) int {
+	for i, p := range t.Partitions {
+		if equalValues(p.Values, values) {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddPartition registers a partition, replacing any with the same values.
+func (t *Table) AddPartition(p Partition) {
+	if i := t.FindPartition(p.Values); i >= 0 {
+		t.Partitions[i] = p
+		return
+	}
+	t.Partitions = append(t.Partitions, p)
+	sort.Slice(t.Partitions, func(i, j int) bool {
+		return t.PartitionName(t.Partitions[i].Values) < t.PartitionName(t.Partitions[j].Values)
+	})
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPartitionKey reports whether name is one of the table's partition columns.
+func (t *Table) IsPartitionKey(name string) bool {
+	for _, c := range t.PartitionKeys {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Catalog is an in-memory registry of Hive table metadata, keyed by database
+// and table name. Names are case-insensitive, as in Hive.
+type Catalog struct {
+	tables map[string]*Table
+}
+
+// New returns an empty catalog.
+func New() *Catalog {
+	return &Catalog{tables: make(map[string]*Table)}
+}
+
+// Table looks up a table by database and name.
+func (c *Catalog) Table(db, name string) (*Table, bool) {
+	t, ok := c.tables[key(db, name)]
+	return t, ok
+}
+
+// Put registers or replaces a table.
+func (c *Catalog) Put(t *Table) {
+	t.Database = strings.ToLower(t.Database)
+	t.Name = strings.ToLower(t.Name)
+	c.tables[key(t.Database, t.Name)] = t
+}
+
+// Drop removes a table. It is a no-op if the table is unknown.
+func (c *Catalog) Drop(db, name string) {
+	delete(c.tables, key(db, name))
+}
+
+// Tables returns the tables of a database sorted by name.
+func (c *Catalog) Tables(db string) []*Table {
+	db = strings.ToLower(db)
+	var tables []*Table
+	for _, t := range c.tables {
+		if t.Database == db {
+			tables = append(tables, t)
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// All returns every table sorted by database and name.
+func (c *Catalog) All() []*Table {
+	tables := make([]*Table, 0, len(c.tables))
+	for _, t := range c.tables {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return key(tables[i].Database, tables[i].Name) < key(tables[j].Database, tables[j].Name)
+	})
+	return tables
+}
+
+func key(db, name string) string {
+	return strings.ToLower(db) + "." + strings.ToLower(name)
+}
+
+// Hive SerDe and input/output format classes of the STORED AS formats.
+var storageClasses = map[string][3]string{
+	"TEXTFILE": {
+		"org.apache.hadoop.hive.serde2.lazy.LazySimpleSerDe",
+		"org.apache.hadoop.mapred.TextInputFormat",
+		"org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat",
+	},
+	"PARQUET": {
+		"org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe",
+		"org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat",
+		"org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat",
+	},
+	"ORC": {
+		"org.apache.hadoop.hive.ql.io.orc.OrcSerde",
+		"org.apache.hadoop.hive.ql.io.orc.OrcInputFormat",
+		"org.apache.hadoop.hive.ql.io.orc.OrcOutputFormat",
+	},
+	"AVRO": {
+		"org.apache.hadoop.hive.serde2.avro.AvroSerDe",
+		"org.apache.hadoop.hive.ql.io.avro.AvroContainerInputFormat",
+		"org.apache.hadoop.hive.ql.io.avro.AvroContainerOutputFormat",
+	},
+	"JSONFILE": {
+		"org.apache.hadoop.hive.serde2.JsonSerDe",
+		"org.apache.hadoop.mapred.TextInputFormat",
+		"org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat",
+	},
+	"SEQUENCEFILE": {
+		"org.apache.hadoop.hive.serde2.lazy.LazySimpleSerDe",
+		"org.apache.hadoop.mapred.SequenceFileInputFormat",
+		"org.apache.hadoop.hive.ql.io.HiveSequenceFileOutputFormat",
+	},
+	"RCFILE": {
+		"org.apache.hadoop.hive.serde2.columnar.LazyBinaryColumnarSerDe",
+		"org.apache.hadoop.hive.ql.io.RCFileInputFormat",
+		"org.apache.hadoop.hive.ql.io.RCFileOutputFormat",
+	},
+}
+
+// Classes returns the SerDe, InputFormat and OutputFormat class names of the
+// storage, as the Hive metastore would record them.
+func (s Storage) Classes() (serde, input, output string) {
+	def, ok := storageClasses[strings.ToUpper(s.Format)]
+	if !ok {
+		def = storageClasses["TEXTFILE"]
+	}
+	serde, input, output = def[0], def[1], def[2]
+	if s.SerDe != "" {
+		serde = s.SerDe
+	}
+	if s.InputFormat != "" {
+		input = s.InputFormat
+	}
+	if s.OutputFormat != "" {
+		output = s.OutputFormat
+	}
+	return serde, input, output
+}
+
+// Params returns the storage descriptor parameters Hive derives from
+// ROW FORMAT DELIMITED, merged with the SerDe properties.
+func (s Storage) Params() map[string]string {
+	params := make(map[string]string, len(s.SerDeProperties)+6)
+	for k, v := range s.SerDeProperties {
+		params[k] = v
+	}
+	if s.FieldDelim != "" {
+		params["field.delim"] = s.FieldDelim
+		params["serialization.format"] = s.FieldDelim
+	} else if s.SerDe == "" && (s.Format == "" || strings.EqualFold(s.Format, "TEXTFILE")) {
+		params["serialization.format"] = "1"
+	}
+	if s.EscapeDelim != "" {
+		params["escape.delim"] = s.EscapeDelim
+	}
+	if s.CollectionDelim != "" {
+		// Hive has always spelled this key this way.
+		params["colelction.delim"] = s.CollectionDelim
+	}
+	if s.MapKeyDelim != "" {
+		params["mapkey.delim"] = s.MapKeyDelim
+	}
+	if s.LineDelim != "" {
+		params["line.delim"] = s.LineDelim
+	}
+	if s.NullFormat != "" {
+		params["serialization.null.format"] = s.NullFormat
+	}
+	return params
+}
+
+// SetParam sets a storage descriptor parameter, as ALTER TABLE ... SET
+// SERDEPROPERTIES does. The delimiter keys that Params derives from ROW
+// FORMAT DELIMITED update the corresponding fields.
+func (s *Storage) SetParam(key, value string) {
+	switch key {
+	case "field.delim":
+		s.FieldDelim = value
+	case "escape.delim":
+		s.EscapeDelim = value
+	case "colelction.delim", "collection.delim":
+		s.CollectionDelim = value
+	case "mapkey.delim":
+		s.MapKeyDelim = value
+	case "line.delim":
+		s.LineDelim = value
+	case "serialization.null.format":
+		s.NullFormat = value
+	default:
+		if s.SerDeProperties == nil {
+			s.SerDeProperties = make(map[string]string)
+		}
+		s.SerDeProperties[key] = value
+	}
+}
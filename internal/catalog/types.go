@@ -0,0 +1,434 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DuckDBType translates a Hive column type into the equivalent DuckDB type,
+// e.g. "array<struct<a:string,b:int>>" becomes "STRUCT(a VARCHAR, b INTEGER)[]".
+func DuckDBType(hiveType string) (string, error) {
+	p := &typeParser{s: hiveType}
+	t, err := p.parse()
+	if err != nil {
+		return "", err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return "", fmt.Errorf("unexpected %q in type %q", p.s[p.pos:], hiveType)
+	}
+	return t, nil
+}
+
+// HiveTypeName returns a Hive column type spelled as Hive prints it, in
+// DESCRIBE or SHOW CREATE TABLE: lower case, with synonyms such as integer
+// or numeric replaced, e.g. "ARRAY<INTEGER>" becomes "array<int>".
+func HiveTypeName(hiveType string) (string, error) {
+	p := &typeParser{s: hiveType}
+	t, err := p.parseHive()
+	if err != nil {
+		return "", err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return "", fmt.Errorf("unexpected %q in type %q", p.s[p.pos:], hiveType)
+	}
+	return t, nil
+}
+
+type typeParser struct {
+	s   string
+	pos int
+}
+
+func (p *typeParser) parse() (string, error) {
+	name := strings.ToUpper(p.word())
+	if name == "" {
+		return "", fmt.Errorf("expected type in %q", p.s)
+	}
+
+	switch name {
+	case "ARRAY":
+		if err := p.expect('<'); err != nil {
+			return "", err
+		}
+		elem, err := p.parse()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect('>'); err != nil {
+			return "", err
+		}
+		return elem + "[]", nil
+
+	case "MAP":
+		if err := p.expect('<'); err != nil {
+			return "", err
+		}
+		k, err := p.parse()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect(','); err != nil {
+			return "", err
+		}
+		v, err := p.parse()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect('>'); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("MAP(%s, %s)", k, v), nil
+
+	case "STRUCT":
+		if err := p.expect('<'); err != nil {
+			return "", err
+		}
+		var fields []string
+		for {
+			field := p.word()
+			if field == "" {
+				return "", fmt.Errorf("expected struct field name in %q", p.s)
+			}
+			if err := p.expect(':'); err != nil {
+				return "", err
+			}
+			ft, err := p.parse()
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, QuoteIdent(field)+" "+ft)
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			break
+		}
+		if err := p.expect('>'); err != nil {
+			return "", err
+		}
+		return "STRUCT(" + strings.Join(fields, ", ") + ")", nil
+
+	case "UNIONTYPE":
+		return "", fmt.Errorf("uniontype is not supported")
+	}
+
+	args := p.args()
+	switch name {
+	case "STRING", "VARCHAR", "CHAR":
+		return "VARCHAR", nil
+	case "INT", "INTEGER":
+		return "INTEGER", nil
+	case "TINYINT", "SMALLINT", "BIGINT", "FLOAT", "BOOLEAN", "DATE", "TIMESTAMP":
+		return name, nil
+	case "DOUBLE":
+		if strings.EqualFold(p.peekWord(), "PRECISION") {
+			p.word()
+		}
+		return "DOUBLE", nil
+	case "DECIMAL", "NUMERIC":
+		if args == "" {
+			// Hive's DECIMAL defaults to DECIMAL(10,0).
+			return "DECIMAL(10,0)", nil
+		}
+		return "DECIMAL(" + args + ")", nil
+	case "BINARY":
+		return "BLOB", nil
+	case "INTERVAL":
+		return "INTERVAL", nil
+	}
+	return "", fmt.Errorf("unknown Hive type %q", name)
+}
+
+func (p *typeParser) parseHive() (string, error) {
+	name := strings.ToUpper(p.word())
+	if name == "" {
+		return "", fmt.Errorf("expected type in %q", p.s)
+	}
+
+	switch name {
+	case "ARRAY", "MAP", "STRUCT", "UNIONTYPE":
+		if err := p.expect('<'); err != nil {
+			return "", err
+		}
+		var parts []string
+		for {
+			part := ""
+			if name == "STRUCT" {
+				field := p.word()
+				if field == "" {
+					return "", fmt.Errorf("expected struct field name in %q", p.s)
+				}
+				if err := p.expect(':'); err != nil {
+					return "", err
+				}
+				if strings.IndexFunc(field, func(r rune) bool { return r > 127 || !isWordByte(byte(r)) }) >= 0 {
+					field = "`" + field + "`"
+				}
+				part = field + ":"
+			}
+			t, err := p.parseHive()
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part+t)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect('>'); err != nil {
+			return "", err
+		}
+		return strings.ToLower(name) + "<" + strings.Join(parts, ",") + ">", nil
+	}
+
+	args := p.args()
+	switch name {
+	case "STRING":
+		return "string", nil
+	case "VARCHAR", "CHAR":
+		if args == "" {
+			return "string", nil
+		}
+		return strings.ToLower(name) + "(" + args + ")", nil
+	case "INT", "INTEGER":
+		return "int", nil
+	case "DOUBLE":
+		if strings.EqualFold(p.peekWord(), "PRECISION") {
+			p.word()
+		}
+		return "double", nil
+	case "DECIMAL", "NUMERIC":
+		if args == "" {
+			return "decimal(10,0)", nil
+		}
+		return "decimal(" + args + ")", nil
+	case "TINYINT", "SMALLINT", "BIGINT", "FLOAT", "BOOLEAN", "DATE", "TIMESTAMP", "BINARY", "INTERVAL":
+		return strings.ToLower(name), nil
+	}
+	return "", fmt.Errorf("unknown Hive type %q", name)
+}
+
+// args consumes an optional parenthesized argument list such as "(10,2)".
+func (p *typeParser) args() string {
+	if p.peek() != '(' {
+		return ""
+	}
+	end := strings.IndexByte(p.s[p.pos:], ')')
+	if end < 0 {
+		return ""
+	}
+	a := strings.ReplaceAll(p.s[p.pos+1:p.pos+end], " ", "")
+	p.pos += end + 1
+	return a
+}
+
+func (p *typeParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '`' {
+		end := strings.IndexByte(p.s[p.pos+1:], '`')
+		if end >= 0 {
+			p.pos += end + 2
+			return p.s[start+1 : p.pos-1]
+		}
+	}
+	for p.pos < len(p.s) && isWordByte(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *typeParser) peekWord() string {
+	save := p.pos
+	w := p.word()
+	p.pos = save
+	return w
+}
+
+func (p *typeParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *typeParser) expect(ch byte) error {
+	if p.peek() != ch {
+		return fmt.Errorf("expected %q in type %q", ch, p.s)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *typeParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func isWordByte(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// QuoteIdent quotes an identifier for DuckDB unless it is a plain lowercase
+// word that cannot collide with a keyword.
+func QuoteIdent(s string) string {
+	plain := s != "" && !(s[0] >= '0' && s[0] <= '9') && !reservedWords[strings.ToLower(s)]
+	for i := 0; i < len(s) && plain; i++ {
+		plain = s[i] == '_' || (s[i] >= 'a' && s[i] <= 'z') || (s[i] >= '0' && s[i] <= '9')
+	}
+	if plain {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// reservedWords are DuckDB keywords that commonly appear as Hive column names.
+var reservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "both": true, "case": true, "cast": true, "check": true,
+	"collate": true, "column": true, "constraint": true, "create": true, "default": true,
+	"desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true,
+	"false": true, "fetch": true, "for": true, "foreign": true, "from": true, "grant": true,
+	"group": true, "having": true, "in": true, "intersect": true, "into": true, "leading": true,
+	"limit": true, "not": true, "null": true, "offset": true, "on": true, "only": true,
+	"or": true, "order": true, "placing": true, "primary": true, "references": true,
+	"returning": true, "select": true, "some": true, "symmetric": true, "table": true,
+	"then": true, "to": true, "trailing": true, "true": true, "union": true, "unique": true,
+	"user": true, "using": true, "variadic": true, "when": true, "where": true, "window": true,
+	"with": true,
+}
+
+// HiveType translates a DuckDB column type, as reported by duckdb_columns(),
+// back into Hive's type syntax, e.g. "STRUCT(a VARCHAR, b INTEGER)[]" becomes
+// "array<struct<a:string,b:int>>". Types without a Hive equivalent map to the
+// closest one.
+func HiveType(duckType string) string {
+	p := &typeParser{s: duckType}
+	t := p.parseDuckDB()
+	if p.skipSpace(); p.pos < len(p.s) {
+		return strings.ToLower(duckType)
+	}
+	return t
+}
+
+func (p *typeParser) parseDuckDB() string {
+	name := strings.ToUpper(p.word())
+	var t string
+	switch name {
+	case "STRUCT":
+		var fields []string
+		if p.peek() == '(' {
+			p.pos++
+			for p.peek() != ')' && p.pos < len(p.s) {
+				start := p.pos
+				field := p.duckIdent()
+				fields = append(fields, field+":"+p.parseDuckDB())
+				if p.peek() == ',' {
+					p.pos++
+				}
+				if p.pos == start {
+					break
+				}
+			}
+			p.pos++
+		}
+		t = "struct<" + strings.Join(fields, ",") + ">"
+	case "MAP":
+		var k, v string
+		if p.peek() == '(' {
+			p.pos++
+			k = p.parseDuckDB()
+			if p.peek() == ',' {
+				p.pos++
+			}
+			v = p.parseDuckDB()
+			if p.peek() == ')' {
+				p.pos++
+			}
+		}
+		t = "map<" + k + "," + v + ">"
+	default:
+		t = hiveScalar(name, p)
+	}
+
+	// Lists and fixed-size arrays: T[] or T[3]
+	for p.peek() == '[' {
+		end := strings.IndexByte(p.s[p.pos:], ']')
+		if end < 0 {
+			break
+		}
+		p.pos += end + 1
+		t = "array<" + t + ">"
+	}
+	return t
+}
+
+// duckIdent reads a struct field name, which DuckDB quotes when needed.
+func (p *typeParser) duckIdent() string {
+	if p.peek() != '"' {
+		return p.word()
+	}
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		if p.s[p.pos] == '"' {
+			if p.pos+1 < len(p.s) && p.s[p.pos+1] == '"' {
+				b.WriteByte('"')
+				p.pos++
+				continue
+			}
+			p.pos++
+			break
+		}
+		b.WriteByte(p.s[p.pos])
+	}
+	return "`" + b.String() + "`"
+}
+
+func hiveScalar(name string, p *typeParser) string {
+	args := p.args()
+	switch name {
+	case "VARCHAR", "UUID", "JSON", "BIT", "ENUM":
+		return "string"
+	case "INTEGER", "INT", "INT4", "UINTEGER", "USMALLINT":
+		return "int"
+	case "BIGINT", "INT8", "UBIGINT":
+		return "bigint"
+	case "SMALLINT", "INT2", "UTINYINT":
+		return "smallint"
+	case "TINYINT", "INT1":
+		return "tinyint"
+	case "HUGEINT", "UHUGEINT":
+		return "decimal(38,0)"
+	case "DOUBLE", "FLOAT8":
+		return "double"
+	case "FLOAT", "REAL", "FLOAT4":
+		return "float"
+	case "BOOLEAN", "BOOL":
+		return "boolean"
+	case "DECIMAL", "NUMERIC":
+		if args == "" {
+			return "decimal(18,3)"
+		}
+		return "decimal(" + args + ")"
+	case "BLOB", "BYTEA":
+		return "binary"
+	case "DATE":
+		return "date"
+	case "TIMESTAMP", "DATETIME", "TIMESTAMP_S", "TIMESTAMP_MS", "TIMESTAMP_NS":
+		if strings.EqualFold(p.peekWord(), "WITH") {
+			// TIMESTAMP WITH TIME ZONE
+			p.word()
+			p.word()
+			p.word()
+		}
+		return "timestamp"
+	case "TIMESTAMPTZ":
+		return "timestamp"
+	case "INTERVAL":
+		return "interval_day_time"
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/output"
+)
+
+// newCatalogCmd returns the catalog subcommand, which prints the Hive
+// metadata stored in the _hive_duck_meta schema of the DuckDB databases.
+func newCatalogCmd() *cobra.Command {
+	var flags dbFlags
+
+	cmd := &cobra.Command{
+		Use:          "catalog [db | db.table]",
+		Short:        "List the stored Hive table metadata, or print one table's entry as JSON",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r, err := flags.runner()
+			if err != nil {
+				return err
+			}
+			tables, err := r.Catalog()
+			if err != nil {
+				return err
+			}
+
+			var filter string
+			if len(args) > 0 {
+				filter = strings.ToLower(args[0])
+			}
+			if strings.Contains(filter, ".") {
+				for _, t := range tables {
+					if t.Database+"."+t.Name == filter {
+						b, err := json.MarshalIndent(t, "", "  ")
+						if err != nil {
+							return err
+						}
+						fmt.Println(string(b))
+						return nil
+					}
+				}
+				return fmt.Errorf("table not found in catalog: %s", args[0])
+			}
+
+			var rows [][]any
+			for _, t := range tables {
+				if filter == "" || t.Database == filter {
+					rows = append(rows, catalogRow(t))
+				}
+			}
+			cols := []string{"database", "table", "type", "format", "location", "partition_keys", "partitions"}
+			_, err = output.PrintValues(cols, rows, r.OutputFormat)
+			return err
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+// catalogRow summarizes a catalog entry for the catalog listing.
+func catalogRow(t *catalog.Table) []any {
+	format := t.Storage.Format
+	if format == "" {
+		format = t.Storage.InputFormat
+	}
+	keys := make([]string, len(t.PartitionKeys))
+	for i, k := range t.PartitionKeys {
+		keys[i] = k.Name
+	}
+	return []any{t.Database, t.Name, t.Type, format, t.Location, strings.Join(keys, ","), len(t.Partitions)}
+}
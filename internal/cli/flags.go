@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danieljhkim/hive-duck/internal/config"
+	"github.com/danieljhkim/hive-duck/internal/engine"
+	"github.com/danieljhkim/hive-duck/internal/output"
+)
+
+// dbFlags are the flags of the subcommands that select the DuckDB databases
+// to work on, as the root command's flags of the same names do.
+type dbFlags struct {
+	dbPath       string
+	configPath   string
+	outputFormat string
+	hiveconf     []string
+}
+
+func (f *dbFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.dbPath, "database", ":memory:", "DuckDB database path or :memory:")
+	cmd.Flags().StringVarP(&f.configPath, "config", "c", "", "Path to databases.yaml config file for DB mapping")
+	cmd.Flags().StringVarP(&f.outputFormat, "output", "o", "table", "Output format: table, csv, tsv, json, hive")
+	cmd.Flags().StringArrayVar(&f.hiveconf, "hiveconf", nil, "Hive conf var k=v (repeatable)")
+}
+
+// runner returns the engine.Runner the flags describe.
+func (f *dbFlags) runner() (engine.Runner, error) {
+	outFmt, err := output.ParseFormat(f.outputFormat)
+	if err != nil {
+		return engine.Runner{}, err
+	}
+
+	var dbMap *config.DatabaseMap
+	if f.configPath != "" {
+		dbMap, err = config.LoadDatabaseMap(f.configPath)
+		if err != nil {
+			return engine.Runner{}, fmt.Errorf("load config: %w", err)
+		}
+	}
+	cfg, err := config.FromFlags(f.hiveconf, nil)
+	if err != nil {
+		return engine.Runner{}, err
+	}
+	warehouse, warehouseTables, err := storageSettings(cfg, dbMap)
+	if err != nil {
+		return engine.Runner{}, err
+	}
+
+	return engine.Runner{
+		DBPath:          f.dbPath,
+		OutputFormat:    outFmt,
+		DatabaseMap:     dbMap,
+		Warehouse:       warehouse,
+		WarehouseTables: warehouseTables,
+		Config:          cfg,
+	}, nil
+}
+
+// storageSettings returns the local warehouse directory and whether managed
+// tables keep their data there, from --hiveconf or else the config.
+func storageSettings(cfg *config.Config, dbMap *config.DatabaseMap) (string, bool, error) {
+	warehouse := cfg.HiveConf["hive.metastore.warehouse.dir"]
+	managed := cfg.HiveConf["hive-duck.managed.storage"]
+	if dbMap != nil {
+		if warehouse == "" {
+			warehouse = dbMap.Warehouse
+		}
+		if managed == "" {
+			managed = dbMap.ManagedStorage
+		}
+	}
+	switch strings.ToLower(managed) {
+	case "", "duckdb":
+		return warehouse, false, nil
+	case "warehouse":
+		if warehouse == "" {
+			return "", false, fmt.Errorf("managed storage %q requires a warehouse directory; set hive.metastore.warehouse.dir", managed)
+		}
+		return warehouse, true, nil
+	}
+	return "", false, fmt.Errorf("unknown managed storage %q (want duckdb or warehouse)", managed)
+}
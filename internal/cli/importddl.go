@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danieljhkim/hive-duck/internal/engine"
+	"github.com/danieljhkim/hive-duck/internal/output"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// ddlExtensions are the file extensions import-ddl reads from a directory.
+var ddlExtensions = map[string]bool{".sql": true, ".hql": true, ".ddl": true}
+
+// newImportDDLCmd returns the import-ddl subcommand, which creates local
+// tables from a dump of Hive CREATE statements.
+func newImportDDLCmd() *cobra.Command {
+	var flags dbFlags
+
+	cmd := &cobra.Command{
+		Use:          "import-ddl <dir-or-file>",
+		Short:        "Create empty local tables from Hive CREATE statements, e.g. SHOW CREATE TABLE output",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r, err := flags.runner()
+			if err != nil {
+				return err
+			}
+			files, err := ddlFiles(args[0])
+			if err != nil {
+				return err
+			}
+
+			var stmts []engine.DDLStatement
+			for _, file := range files {
+				b, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				split, err := preprocess.SplitScript(file, string(b))
+				if err != nil {
+					return err
+				}
+				for _, src := range split {
+					stmts = append(stmts, engine.DDLStatement{Source: src.Start().String(), SQL: src.SQL})
+				}
+			}
+
+			results, err := r.ImportDDL(stmts)
+			if err != nil {
+				return err
+			}
+
+			counts := make(map[string]int)
+			rows := make([][]any, len(results))
+			for i, res := range results {
+				counts[res.Status]++
+				rows[i] = []any{res.Source, res.Object, res.Status, res.Reason}
+			}
+			if _, err := output.PrintValues([]string{"source", "object", "status", "reason"}, rows, r.OutputFormat); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%d created, %d skipped, %d failed\n",
+				counts[engine.ImportCreated], counts[engine.ImportSkipped], counts[engine.ImportFailed])
+			if n := counts[engine.ImportFailed]; n > 0 {
+				return fmt.Errorf("%d statement(s) failed", n)
+			}
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+// ddlFiles returns path if it is a file, or the .sql, .hql and .ddl files
+// below it, in lexical order, if it is a directory.
+func ddlFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && ddlExtensions[strings.ToLower(filepath.Ext(p))] {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,9 +17,18 @@ import (
 	"github.com/danieljhkim/hive-duck/internal/preprocess"
 )
 
+// Execute runs the command line. Statements that fail are reported as the
+// Hive CLI reports them, and the process exits with the status Hive would;
+// other errors exit with 1.
 func Execute() {
 	if err := newRootCmd().Execute(); err != nil {
-		os.Exit(2)
+		var failed interface{ ExitCode() int } // engine.StatementError or Failures
+		if errors.As(err, &failed) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(failed.ExitCode())
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
 	}
 }
 
@@ -27,21 +40,33 @@ func newRootCmd() *cobra.Command {
 		configPath        string
 		extsCSV           string
 		silent            bool
+		verbose           int
 		strict            bool
 		dryRun            bool
 		failOnUnsupported bool
+		rawErrors         bool
+		ignoreErrors      bool
+		atomic            bool
+		timeout           time.Duration
+		statementTimeout  time.Duration
 		outputFormat      string
 		hiveconf          []string
 		hivevar           []string
 	)
 
 	cmd := &cobra.Command{
-		Use:   "hive-duck",
-		Short: "Hive-compatible wrapper for -e/-f using DuckDB",
+		Use:           "hive-duck",
+		Short:         "Hive-compatible wrapper for -e/-f using DuckDB",
+		SilenceErrors: true, // printed by Execute
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if (expr == "" && file == "") || (expr != "" && file != "") {
 				return fmt.Errorf("exactly one of -e or -f must be provided")
 			}
+			if atomic && ignoreErrors {
+				return fmt.Errorf("--atomic and --ignore-errors can not be combined")
+			}
+			// From here on errors are about the script, not the usage.
+			cmd.SilenceUsage = true
 
 			// Parse output format
 			outFmt, err := output.ParseFormat(outputFormat)
@@ -76,14 +101,8 @@ func newRootCmd() *cobra.Command {
 				sqlText = string(b)
 			}
 
-			// Substitute vars
-			sqlText, err = preprocess.Substitute(sqlText, cfg)
-			if err != nil {
-				return err
-			}
-
-			// Split statements
-			stmts, err := preprocess.SplitStatements(sqlText)
+			// Split statements, keeping their positions in the file
+			stmts, err := preprocess.SplitScript(file, sqlText)
 			if err != nil {
 				return err
 			}
@@ -93,7 +112,7 @@ func newRootCmd() *cobra.Command {
 			if len(unsupported) > 0 {
 				// Print warnings to stderr
 				for _, u := range unsupported {
-					fmt.Fprintf(os.Stderr, "WARNING: Unsupported Hive statement: %s\n", u.Keyword)
+					fmt.Fprintf(os.Stderr, "%s: WARNING: Unsupported Hive statement: %s\n", u.Position, u.Keyword)
 					fmt.Fprintf(os.Stderr, "  Statement: %s\n", u.Statement)
 					fmt.Fprintf(os.Stderr, "  Reason: %s\n\n", u.Reason)
 				}
@@ -103,9 +122,18 @@ func newRootCmd() *cobra.Command {
 				}
 			}
 
+			// Local warehouse directory for non-LOCAL file paths and, if
+			// so configured, managed tables
+			warehouse, warehouseTables, err := storageSettings(cfg, dbMap)
+			if err != nil {
+				return err
+			}
+
 			// Rewrite Hive statements to DuckDB equivalents
 			rewriteOpts := &preprocess.RewriteOptions{
-				DatabaseMap: dbMap,
+				DatabaseMap:     dbMap,
+				WarehouseTables: warehouseTables,
+				Config:          cfg, // substitutes vars statement by statement
 			}
 			rewriteResult, err := preprocess.Rewrite(stmts, rewriteOpts)
 			if err != nil {
@@ -115,7 +143,7 @@ func newRootCmd() *cobra.Command {
 			// Dry-run mode: print rewritten SQL and exit
 			if dryRun {
 				for _, stmt := range rewriteResult.Statements {
-					fmt.Println(stmt + ";")
+					fmt.Println(stmt.String() + ";")
 				}
 				return nil
 			}
@@ -132,13 +160,31 @@ func newRootCmd() *cobra.Command {
 			}
 
 			r := engine.Runner{
-				DBPath:       dbPath,
-				Exts:         exts,
-				Silent:       silent,
-				OutputFormat: outFmt,
-				DatabaseMap:  dbMap,
+				DBPath:           dbPath,
+				Exts:             exts,
+				Silent:           silent,
+				OutputFormat:     outFmt,
+				DatabaseMap:      dbMap,
+				Warehouse:        warehouse,
+				WarehouseTables:  warehouseTables,
+				Config:           cfg,
+				Verbose:          verbose,
+				RawErrors:        rawErrors,
+				IgnoreErrors:     ignoreErrors,
+				Atomic:           atomic,
+				Timeout:          timeout,
+				StatementTimeout: statementTimeout,
 			}
-			return r.Run(rewriteResult.Statements)
+
+			// Ctrl-C and SIGTERM interrupt the running statement; a
+			// second one kills the process.
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				stop()
+			}()
+			return r.Run(ctx, rewriteResult.Statements)
 		},
 	}
 
@@ -148,13 +194,21 @@ func newRootCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&configPath, "config", "c", "", "Path to databases.yaml config file for DB mapping")
 	cmd.Flags().StringVar(&extsCSV, "ext", "", "Comma-separated DuckDB extensions to INSTALL/LOAD (e.g. avro,httpfs,json)")
 	cmd.Flags().BoolVarP(&silent, "silent", "S", false, "Suppress non-result output")
+	cmd.Flags().CountVarP(&verbose, "verbose", "v", "Echo each statement on stderr before running it, and how SET keys take effect; -vv also echoes the rewritten statements")
 	cmd.Flags().BoolVar(&strict, "strict-vars", true, "Fail if a referenced hiveconf/hivevar/env var is missing")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print rewritten SQL without executing")
 	cmd.Flags().BoolVar(&failOnUnsupported, "fail-on-unsupported", false, "Fail if unsupported Hive statements are detected")
-	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table, csv, tsv, json")
+	cmd.Flags().BoolVar(&rawErrors, "raw-errors", false, "Append DuckDB's own message to the Hive error of a failed statement")
+	cmd.Flags().BoolVar(&ignoreErrors, "ignore-errors", false, "Keep running the script after a statement fails, as hive.cli.errors.ignore=true")
+	cmd.Flags().BoolVar(&atomic, "atomic", false, "Run the script in a single transaction, rolled back if a statement fails")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Fail the script if it runs longer than this, e.g. 30m (0: no limit)")
+	cmd.Flags().DurationVar(&statementTimeout, "statement-timeout", 0, "Fail a statement that runs longer than this, e.g. 5m (0: no limit)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table, csv, tsv, json, hive")
 
 	cmd.Flags().StringArrayVar(&hiveconf, "hiveconf", nil, "Hive conf var k=v (repeatable)")
 	cmd.Flags().StringArrayVar(&hivevar, "hivevar", nil, "Hive var name=v (repeatable)")
 
+	cmd.AddCommand(newCatalogCmd(), newImportDDLCmd())
+
 	return cmd
 }
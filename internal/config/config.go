@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"os/user"
+	"sort"
 	"strings"
 )
 
@@ -10,7 +12,10 @@ type Config struct {
 	HiveConf   map[string]string
 	HiveVar    map[string]string
 	Env        map[string]string
+	System     map[string]string // Java system properties Hive scripts commonly read
 	StrictVars bool
+
+	initial *Config // the values from the flags, which RESET restores
 }
 
 func FromFlags(hiveconf []string, hivevar []string) (*Config, error) {
@@ -18,6 +23,7 @@ func FromFlags(hiveconf []string, hivevar []string) (*Config, error) {
 		HiveConf: make(map[string]string),
 		HiveVar:  make(map[string]string),
 		Env:      make(map[string]string),
+		System:   systemProperties(),
 	}
 	for _, kv := range hiveconf {
 		k, v, err := parseKV(kv)
@@ -40,9 +46,130 @@ func FromFlags(hiveconf []string, hivevar []string) (*Config, error) {
 			cfg.Env[e[:i]] = e[i+1:]
 		}
 	}
+	cfg.initial = cfg.Clone()
 	return cfg, nil
 }
 
+// systemProperties returns the Java system properties that have a local
+// equivalent.
+func systemProperties() map[string]string {
+	props := make(map[string]string)
+	if u, err := user.Current(); err == nil {
+		props["user.name"] = u.Username
+		props["user.home"] = u.HomeDir
+	}
+	if dir, err := os.Getwd(); err == nil {
+		props["user.dir"] = dir
+	}
+	return props
+}
+
+// Clone returns a copy of the config that can be changed independently.
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.HiveConf = copyMap(c.HiveConf)
+	clone.HiveVar = copyMap(c.HiveVar)
+	clone.Env = copyMap(c.Env)
+	clone.System = copyMap(c.System)
+	return &clone
+}
+
+// Set applies SET key=value. As in Hive, a hivevar: key sets a variable, a
+// system: key a system property and other keys, with or without the
+// hiveconf: prefix, a conf var; env: keys cannot be set.
+func (c *Config) Set(key, value string) error {
+	prefix, name := splitKey(key)
+	switch prefix {
+	case "hivevar":
+		c.HiveVar[name] = value
+	case "system":
+		c.System[name] = value
+	case "env":
+		return fmt.Errorf("env:* variables can not be set")
+	default:
+		c.HiveConf[name] = value
+	}
+	return nil
+}
+
+// Get returns the value SET key prints, with the same prefixes as Set.
+func (c *Config) Get(key string) (string, bool) {
+	prefix, name := splitKey(key)
+	var v string
+	var ok bool
+	switch prefix {
+	case "hivevar":
+		v, ok = c.HiveVar[name]
+	case "system":
+		v, ok = c.System[name]
+	case "env":
+		v, ok = c.Env[name]
+	default:
+		v, ok = c.HiveConf[name]
+	}
+	return v, ok
+}
+
+// Reset restores the conf vars and system properties to their values from
+// the flags, as RESET does. Variables set with SET hivevar: are kept.
+func (c *Config) Reset() {
+	if c.initial == nil {
+		return
+	}
+	c.HiveConf = copyMap(c.initial.HiveConf)
+	c.System = copyMap(c.initial.System)
+}
+
+// Entries returns the key=value lines SET prints: the conf vars, then the
+// hivevar: and system: entries, each sorted, and with env the env: entries.
+func (c *Config) Entries(env bool) []string {
+	var lines []string
+	add := func(prefix string, m map[string]string) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			lines = append(lines, prefix+k+"="+m[k])
+		}
+	}
+	add("", c.HiveConf)
+	add("hivevar:", c.HiveVar)
+	add("system:", c.System)
+	if env {
+		add("env:", c.Env)
+	}
+	return lines
+}
+
+// ConfKey returns the conf var a SET key names, without its hiveconf:
+// prefix, and false for variables, system properties and env: keys.
+func ConfKey(key string) (string, bool) {
+	prefix, name := splitKey(key)
+	return name, prefix == "" || prefix == "hiveconf"
+}
+
+// splitKey splits the hiveconf:, hivevar:, system: or env: prefix off a
+// SET key.
+func splitKey(key string) (string, string) {
+	if i := strings.IndexByte(key, ':'); i > 0 {
+		switch prefix := strings.ToLower(key[:i]); prefix {
+		case "hiveconf", "hivevar", "system", "env":
+			return prefix, key[i+1:]
+		}
+	}
+	return "", key
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
 func parseKV(s string) (string, string, error) {
 	i := strings.IndexByte(s, '=')
 	if i <= 0 {
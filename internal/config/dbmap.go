@@ -10,8 +10,79 @@ import (
 
 // DatabaseMap holds the mapping of Hive database names to DuckDB database paths.
 type DatabaseMap struct {
-	Databases map[string]string `yaml:"databases"` // db_name -> path/to/file.duckdb
-	Default   string            `yaml:"default"`   // Default database to USE on startup
+	Databases map[string]string // db_name -> path/to/file.duckdb
+	Default   string            // Default database to USE on startup
+	Warehouse string            // Local directory standing in for hive.metastore.warehouse.dir
+
+	// ManagedStorage is where managed tables keep their data: "duckdb" (the
+	// default) or "warehouse", as Parquet files under Warehouse.
+	ManagedStorage string
+
+	// Warehouses holds the databases of kind warehouse: db_name -> a local
+	// copy of a Hive warehouse directory whose <db>.db/<table> directories
+	// are registered as tables, with the tables directly under it going to
+	// db_name.
+	Warehouses map[string]string
+
+	// settings changes the translation of conf keys to DuckDB settings; see
+	// Settings.
+	settings map[string]Setting
+}
+
+// DatabaseEntry is a database given as a mapping rather than a path:
+//
+//	databases:
+//	  prod:
+//	    kind: warehouse
+//	    path: ./warehouse_copy
+type DatabaseEntry struct {
+	Kind string `yaml:"kind"` // duckdb (the default) or warehouse
+	Path string `yaml:"path"`
+}
+
+// UnmarshalYAML decodes the databases config, where each database is either
+// a DuckDB path or a DatabaseEntry.
+func (m *DatabaseMap) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Databases map[string]yaml.Node `yaml:"databases"`
+		Default   string               `yaml:"default"`
+		Warehouse string               `yaml:"warehouse"`
+		Managed   string               `yaml:"managed_storage"`
+		Settings  map[string]yaml.Node `yaml:"settings"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	settings, err := decodeSettings(raw.Settings)
+	if err != nil {
+		return err
+	}
+	m.settings = settings
+	m.Default, m.Warehouse, m.ManagedStorage = raw.Default, raw.Warehouse, raw.Managed
+	m.Databases = make(map[string]string)
+	m.Warehouses = make(map[string]string)
+	for name, node := range raw.Databases {
+		if node.Kind == yaml.ScalarNode {
+			m.Databases[name] = node.Value
+			continue
+		}
+		var e DatabaseEntry
+		if err := node.Decode(&e); err != nil {
+			return fmt.Errorf("database %s: %w", name, err)
+		}
+		if e.Path == "" {
+			return fmt.Errorf("database %s: path is required", name)
+		}
+		switch e.Kind {
+		case "", "duckdb":
+			m.Databases[name] = e.Path
+		case "warehouse":
+			m.Warehouses[name] = e.Path
+		default:
+			return fmt.Errorf("database %s: unknown kind %q (want duckdb or warehouse)", name, e.Kind)
+		}
+	}
+	return nil
 }
 
 // LoadDatabaseMap loads a database mapping from a YAML file.
@@ -37,6 +108,14 @@ func LoadDatabaseMap(path string) (*DatabaseMap, error) {
 			dbMap.Databases[name] = filepath.Join(configDir, dbPath)
 		}
 	}
+	for name, root := range dbMap.Warehouses {
+		if !filepath.IsAbs(root) {
+			dbMap.Warehouses[name] = filepath.Join(configDir, root)
+		}
+	}
+	if dbMap.Warehouse != "" && !filepath.IsAbs(dbMap.Warehouse) {
+		dbMap.Warehouse = filepath.Join(configDir, dbMap.Warehouse)
+	}
 
 	return &dbMap, nil
 }
@@ -70,3 +149,16 @@ func (m *DatabaseMap) DatabaseNames() []string {
 	}
 	return names
 }
+
+// AddDatabase maps a database name to a DuckDB path, as CREATE DATABASE does.
+func (m *DatabaseMap) AddDatabase(name, path string) {
+	if m.Databases == nil {
+		m.Databases = make(map[string]string)
+	}
+	m.Databases[name] = path
+}
+
+// RemoveDatabase removes a database mapping, as DROP DATABASE does.
+func (m *DatabaseMap) RemoveDatabase(name string) {
+	delete(m.Databases, name)
+}
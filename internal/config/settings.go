@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Setting is the DuckDB setting a Hive conf key translates to. Unit is
+// appended to the value, as memory sizes in Hive are numbers of megabytes.
+type Setting struct {
+	Name string `yaml:"setting"`
+	Unit string `yaml:"unit"`
+}
+
+// CopyCompression is not a DuckDB setting but the compression of the
+// Parquet files hive-duck writes with COPY, which DuckDB has no setting for.
+const CopyCompression = "copy_compression"
+
+// DefaultSettings translates the Hive and MapReduce conf keys that have a
+// local meaning.
+var DefaultSettings = map[string]Setting{
+	"mapreduce.map.memory.mb":          {Name: "memory_limit", Unit: "MB"},
+	"mapreduce.reduce.memory.mb":       {Name: "memory_limit", Unit: "MB"},
+	"hive.tez.container.size":          {Name: "memory_limit", Unit: "MB"},
+	"hive.exec.parallel.thread.number": {Name: "threads"},
+	"hive.exec.scratchdir":             {Name: "temp_directory"},
+	"hive.exec.orc.default.compress":   {Name: CopyCompression},
+	"parquet.compression":              {Name: CopyCompression},
+}
+
+// Settings returns the translation of conf keys to DuckDB settings: the
+// defaults, changed by the settings section of the config, in which a key
+// maps to a setting name or to a Setting, and an empty name drops a default:
+//
+//	settings:
+//	  hive.auto.convert.join.noconditionaltask.size: ""
+//	  spark.executor.cores: threads
+//	  spark.executor.memory: {setting: memory_limit}
+func (m *DatabaseMap) Settings() map[string]Setting {
+	settings := make(map[string]Setting, len(DefaultSettings))
+	for k, v := range DefaultSettings {
+		settings[k] = v
+	}
+	if m == nil {
+		return settings
+	}
+	for k, v := range m.settings {
+		if v.Name == "" {
+			delete(settings, k)
+		} else {
+			settings[k] = v
+		}
+	}
+	return settings
+}
+
+// decodeSettings decodes the settings section of the config.
+func decodeSettings(nodes map[string]yaml.Node) (map[string]Setting, error) {
+	settings := make(map[string]Setting, len(nodes))
+	for key, node := range nodes {
+		var s Setting
+		if node.Kind == yaml.ScalarNode {
+			s.Name = node.Value
+		} else if err := node.Decode(&s); err != nil {
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		}
+		settings[key] = s
+	}
+	return settings, nil
+}
@@ -0,0 +1,447 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Column changes on file-backed tables only change the schema their files
+// are read with, as in Hive. Tables stored in DuckDB are altered in place
+// where DuckDB can (renames, type changes, appended columns) and rebuilt
+// otherwise: DuckDB cannot reorder or drop columns in place, and partition
+// columns must stay last.
+
+// alterTarget looks up a table for ALTER TABLE. The returned entry is the
+// one held by the catalog, registered if the table was created directly in
+// DuckDB, so changes to it are kept.
+func (s *session) alterTarget(name string) (*described, error) {
+	d, err := s.lookupTable(name)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("table not found: %s", name)
+	}
+	if d.table.Type == catalog.VirtualView || d.table.Type == catalog.MaterializedView {
+		return nil, fmt.Errorf("cannot alter view %s with ALTER TABLE", d.ref)
+	}
+	if _, ok := s.catalogFor(d.ref).Table(d.ref.Database, d.ref.Name); !ok {
+		s.catalogFor(d.ref).Put(d.table)
+	}
+	return d, nil
+}
+
+// columnIndex returns the index of the named column, or -1.
+func columnIndex(cols []catalog.Column, name string) int {
+	for i, c := range cols {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *session) changeColumn(c *preprocess.ChangeColumn) error {
+	d, err := s.alterTarget(c.Table)
+	if err != nil {
+		return err
+	}
+	t := d.table
+	cols := append([]catalog.Column{}, d.cols...)
+	i := columnIndex(cols, c.Old)
+	if i < 0 {
+		return fmt.Errorf("invalid column reference %s in %s", c.Old, d.ref)
+	}
+	col := c.Column
+	if j := columnIndex(cols, col.Name); (j >= 0 && j != i) || t.IsPartitionKey(col.Name) {
+		return fmt.Errorf("duplicate column name: %s", col.Name)
+	}
+	if col.Comment == "" {
+		col.Comment = cols[i].Comment
+	}
+	cols[i] = col
+
+	moved := c.First || c.After != ""
+	if moved {
+		cols = append(cols[:i], cols[i+1:]...)
+		at := 0
+		if c.After != "" {
+			j := columnIndex(cols, c.After)
+			if j < 0 {
+				return fmt.Errorf("invalid column reference %s in %s", c.After, d.ref)
+			}
+			at = j + 1
+		}
+		cols = append(cols[:at], append([]catalog.Column{col}, cols[at:]...)...)
+	}
+
+	if !t.FileBacked() {
+		sources := sameSources(cols)
+		sources[strings.ToLower(col.Name)] = c.Old
+		if moved {
+			err = s.rebuildTable(d, cols, sources)
+		} else {
+			err = s.alterColumnInPlace(d.ref, c.Old, col)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.setColumns(d, cols)
+}
+
+// alterColumnInPlace renames and retypes a column of a DuckDB table.
+func (s *session) alterColumnInPlace(ref tableRef, old string, col catalog.Column) error {
+	typ, err := catalog.DuckDBType(col.Type)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(old, col.Name) {
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", ref.sql(), ident(old), ident(col.Name))); err != nil {
+			return err
+		}
+	}
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", ref.sql(), ident(col.Name), typ))
+	return err
+}
+
+func (s *session) addColumns(c *preprocess.AddColumns) error {
+	d, err := s.alterTarget(c.Table)
+	if err != nil {
+		return err
+	}
+	t := d.table
+
+	var cols []catalog.Column
+	if !c.Replace {
+		cols = append(cols, d.cols...)
+	}
+	for _, col := range c.Columns {
+		if columnIndex(cols, col.Name) >= 0 || t.IsPartitionKey(col.Name) {
+			return fmt.Errorf("duplicate column name: %s", col.Name)
+		}
+		cols = append(cols, col)
+	}
+
+	if !t.FileBacked() {
+		if c.Replace || len(t.PartitionKeys) > 0 {
+			// Columns whose names survive a REPLACE COLUMNS keep their data.
+			sources := make(map[string]string)
+			for _, col := range cols {
+				if columnIndex(d.cols, col.Name) >= 0 {
+					sources[strings.ToLower(col.Name)] = col.Name
+				}
+			}
+			err = s.rebuildTable(d, cols, sources)
+		} else {
+			err = s.appendColumns(d.ref, c.Columns)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.setColumns(d, cols)
+}
+
+func (s *session) appendColumns(ref tableRef, cols []catalog.Column) error {
+	for _, col := range cols {
+		typ, err := catalog.DuckDBType(col.Type)
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", ref.sql(), ident(col.Name), typ)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setColumns records a table's new data columns, re-reading the files of a
+// file-backed table with them.
+func (s *session) setColumns(d *described, cols []catalog.Column) error {
+	d.table.Columns = cols
+	if d.table.FileBacked() {
+		return s.refreshFileTable(d.ref, d.table)
+	}
+	return nil
+}
+
+// sameSources maps every column to itself, for rebuildTable.
+func sameSources(cols []catalog.Column) map[string]string {
+	sources := make(map[string]string, len(cols))
+	for _, c := range cols {
+		sources[strings.ToLower(c.Name)] = c.Name
+	}
+	return sources
+}
+
+// rebuildTable recreates a DuckDB table with the given data columns followed
+// by its partition columns. sources maps each new column (lowercased) to the
+// existing column its values are cast from; other columns are NULL.
+func (s *session) rebuildTable(d *described, cols []catalog.Column, sources map[string]string) error {
+	exprs := make([]string, 0, len(cols)+len(d.table.PartitionKeys))
+	for _, col := range cols {
+		typ, err := catalog.DuckDBType(col.Type)
+		if err != nil {
+			return err
+		}
+		src := "NULL"
+		if old, ok := sources[strings.ToLower(col.Name)]; ok {
+			src = ident(old)
+		}
+		exprs = append(exprs, fmt.Sprintf("CAST(%s AS %s) AS %s", src, typ, ident(col.Name)))
+	}
+	for _, k := range d.table.PartitionKeys {
+		exprs = append(exprs, ident(k.Name))
+	}
+
+	tmp := d.ref
+	tmp.Name = "__hive_duck_rebuild_" + d.ref.Name
+	return s.inTx(func(tx execer) error {
+		stmts := []string{
+			fmt.Sprintf("CREATE TABLE %s AS SELECT %s FROM %s", tmp.sql(), strings.Join(exprs, ", "), d.ref.sql()),
+			"DROP TABLE " + d.ref.sql(),
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tmp.sql(), ident(d.ref.Name)),
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("rebuild %s: %w", d.ref, err)
+			}
+		}
+		return nil
+	})
+}
+
+// execer runs the statements of a transaction: the transaction itself, or
+// the database in an atomic run, whose transaction spans the script.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// inTx runs fn in a transaction, committing if it succeeds. In an atomic
+// run fn runs in the transaction of the script.
+func (s *session) inTx(fn func(execer) error) error {
+	if s.atomic {
+		return fn(s.db)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := fn(&txConn{tx, s.db.ctx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *session) renameTable(c *preprocess.RenameTable) error {
+	d, err := s.alterTarget(c.Table)
+	if err != nil {
+		return err
+	}
+	t := d.table
+	existing, err := s.lookupTable(c.NewName)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("table already exists: %s", existing.ref)
+	}
+	to, err := s.resolvePermanent(c.NewName)
+	if err != nil {
+		return err
+	}
+	if d.ref.isTemporary() {
+		// A temporary table stays temporary under its new name.
+		if to, err = s.temporarySchema(to); err != nil {
+			return err
+		}
+	}
+
+	kind := "TABLE"
+	if t.FileBacked() {
+		kind = "VIEW"
+	}
+	switch {
+	case to.catalog == d.ref.catalog && to.schema == d.ref.schema:
+		_, err = s.db.Exec(fmt.Sprintf("ALTER %s %s RENAME TO %s", kind, d.ref.sql(), ident(to.Name)))
+	case t.FileBacked():
+		if err = s.refreshFileTable(to, t); err == nil {
+			_, err = s.db.Exec("DROP VIEW " + d.ref.sql())
+		}
+	default:
+		// DuckDB renames only within a schema; copy across databases.
+		if _, err = s.db.Exec(fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM %s", to.sql(), d.ref.sql())); err == nil {
+			_, err = s.db.Exec("DROP TABLE " + d.ref.sql())
+		}
+	}
+	if err == nil && t.WarehouseFiles {
+		err = s.moveTableDir(d.ref, to, t)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.catalogFor(d.ref).Drop(d.ref.Database, d.ref.Name)
+	t.Database, t.Name = to.Database, to.Name
+	s.catalogFor(to).Put(t)
+	return nil
+}
+
+// moveTableDir moves the directory of a renamed managed table kept in the
+// warehouse to its new name, as Hive does unless the table has a LOCATION
+// of its own.
+func (s *session) moveTableDir(from, to tableRef, t *catalog.Table) error {
+	oldDir, err := filepath.Abs(s.tableDir(from, nil))
+	if err != nil || t.Location != "file://"+oldDir {
+		return err
+	}
+	newDir, err := filepath.Abs(s.tableDir(to, nil))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(oldDir); err == nil {
+		if err := s.changesFiles("RENAME of " + from.String()); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(newDir), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(oldDir, newDir); err != nil {
+			return err
+		}
+	}
+	t.Location = "file://" + newDir
+	for i, p := range t.Partitions {
+		if rest, ok := strings.CutPrefix(p.Location, "file://"+oldDir); ok {
+			t.Partitions[i].Location = t.Location + rest
+		}
+	}
+	return s.refreshFileTable(to, t)
+}
+
+// keepStorage fails if an ALTER TABLE changed whether a table's data is
+// read from files or stored in DuckDB, which would strand its data.
+func keepStorage(t *catalog.Table, fileBacked bool) error {
+	if t.FileBacked() != fileBacked {
+		return fmt.Errorf("table %s would switch between files and DuckDB storage; recreate it instead", t.Name)
+	}
+	return nil
+}
+
+func (s *session) setTableProperties(c *preprocess.SetTableProperties) error {
+	d, err := s.alterTarget(c.Table)
+	if err != nil {
+		return err
+	}
+	t := d.table
+	before := *t
+	for k, v := range c.Properties {
+		switch k {
+		case "comment":
+			t.Comment = v
+		case "EXTERNAL":
+			t.Type = catalog.ManagedTable
+			if strings.EqualFold(v, "TRUE") {
+				t.Type = catalog.ExternalTable
+			}
+		default:
+			if t.Properties == nil {
+				t.Properties = make(map[string]string)
+			}
+			t.Properties[k] = v
+		}
+	}
+	if err := keepStorage(t, before.FileBacked()); err != nil {
+		t.Type = before.Type
+		return err
+	}
+	if t.FileBacked() {
+		// skip.header.line.count and the like change how files are read.
+		return s.refreshFileTable(d.ref, t)
+	}
+	return nil
+}
+
+func (s *session) setSerDe(c *preprocess.SetSerDe) error {
+	d, err := s.alterTarget(c.Table)
+	if err != nil {
+		return err
+	}
+	t := d.table
+	if c.SerDe != "" {
+		t.Storage.SerDe = c.SerDe
+	}
+	for k, v := range c.Properties {
+		t.Storage.SetParam(k, v)
+	}
+	if t.FileBacked() {
+		return s.refreshFileTable(d.ref, t)
+	}
+	return nil
+}
+
+// setLocation points a file-backed table or partition at a new directory.
+// For tables stored in DuckDB the location is only recorded.
+func (s *session) setLocation(c *preprocess.SetLocation) error {
+	d, err := s.alterTarget(c.Table)
+	if err != nil {
+		return err
+	}
+	t := d.table
+
+	if len(c.Partition) > 0 {
+		if len(t.PartitionKeys) == 0 {
+			return fmt.Errorf("table %s is not a partitioned table", d.ref)
+		}
+		values, err := specValues(t, c.Partition)
+		if err != nil {
+			return err
+		}
+		all, err := s.partitions(d.ref, t)
+		if err != nil {
+			return err
+		}
+		if !containsValues(all, values) {
+			return fmt.Errorf("partition not found: %s %s", d.ref, c.Partition)
+		}
+		t.AddPartition(catalog.Partition{Values: values, Location: c.Location})
+	} else {
+		before, fileBacked := t.Location, t.FileBacked()
+		t.Location = c.Location
+		if err := keepStorage(t, fileBacked); err != nil {
+			t.Location = before
+			return err
+		}
+	}
+
+	if t.FileBacked() {
+		return s.refreshFileTable(d.ref, t)
+	}
+	return nil
+}
+
+func (s *session) setFileFormat(c *preprocess.SetFileFormat) error {
+	d, err := s.alterTarget(c.Table)
+	if err != nil {
+		return err
+	}
+	st := &d.table.Storage
+	st.Format, st.InputFormat, st.OutputFormat = c.Storage.Format, c.Storage.InputFormat, c.Storage.OutputFormat
+	if c.Storage.Format != "" || c.Storage.SerDe != "" {
+		// A STORED AS name implies its own SerDe.
+		st.SerDe = c.Storage.SerDe
+	}
+	if d.table.FileBacked() {
+		return s.refreshFileTable(d.ref, d.table)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// An atomic run runs the script in a single transaction, committed once
+// every statement succeeded and rolled back when one fails, so that a failed
+// run leaves the databases as they were. It inherits the limits of DuckDB
+// transactions: one transaction writes to a single attached database, and
+// files written or removed stay so. ATTACH, INSTALL, LOAD and PRAGMA
+// settings, which DuckDB does not roll back either, run before the
+// transaction starts; statements that could not be rolled back, and Hive
+// temporary tables, whose database the transaction could not write along
+// with another, are reported before anything runs, and the emulated
+// commands that would change files fail, rolling the run back.
+
+// hoistedPattern matches the statements run before the transaction of an
+// atomic run.
+var hoistedPattern = regexp.MustCompile(`(?is)^(ATTACH|INSTALL|LOAD|FORCE\s+INSTALL)\b|^PRAGMA\s+\w+\s*=`)
+
+// nonTransactionalPattern matches the statements an atomic run can not roll
+// back: those ending or starting transactions, writing files or detaching
+// databases.
+var nonTransactionalPattern = regexp.MustCompile(`(?is)^(BEGIN|START\s+TRANSACTION|COMMIT|END|ROLLBACK|ABORT|DETACH|CHECKPOINT|FORCE\s+CHECKPOINT|VACUUM|EXPORT\s+DATABASE|IMPORT\s+DATABASE)\b|^COPY\s+(\(.*\)|\S+)\s+TO\s`)
+
+// planAtomic splits the statements of an atomic run into those run before
+// the transaction and the rest, or reports those that can not be rolled
+// back and those creating temporary tables.
+func planAtomic(stmts []preprocess.Statement) (hoisted, rest []preprocess.Statement, err error) {
+	var rejected, temporary []string
+	for _, group := range statementGroups(stmts) {
+		if len(group) == 1 && group[0].Command == nil && hoistedPattern.MatchString(strings.TrimSpace(group[0].SQL)) {
+			hoisted = append(hoisted, group...)
+			continue
+		}
+		for _, stmt := range group {
+			if c, ok := stmt.Command.(*preprocess.CreateTable); ok && c.Temporary {
+				temporary = append(temporary, atomicOrigin(stmt))
+				break
+			}
+			if nonTransactional(stmt) {
+				rejected = append(rejected, atomicOrigin(stmt))
+				break
+			}
+		}
+		rest = append(rest, group...)
+	}
+
+	var msgs []string
+	if len(rejected) > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d statement(s) can not be rolled back in an atomic run:\n  %s",
+			len(rejected), strings.Join(rejected, "\n  ")))
+	}
+	if len(temporary) > 0 {
+		// Temporary tables live in a database of their own, and a
+		// transaction writes to a single database.
+		msgs = append(msgs, fmt.Sprintf("%d statement(s) create temporary tables, which an atomic run can not write along with other tables:\n  %s",
+			len(temporary), strings.Join(temporary, "\n  ")))
+	}
+	if len(msgs) > 0 {
+		return nil, nil, errors.New(strings.Join(msgs, "\n"))
+	}
+	return hoisted, rest, nil
+}
+
+// nonTransactional reports whether a statement changes what a rollback
+// does not restore.
+func nonTransactional(stmt preprocess.Statement) bool {
+	switch stmt.Command.(type) {
+	case *preprocess.CreateDatabase, *preprocess.DropDatabase, *preprocess.ExportTable:
+		// Databases are files attached or detached, and exports are files.
+		return true
+	}
+	return nonTransactionalPattern.MatchString(strings.TrimSpace(stmt.SQL))
+}
+
+// atomicOrigin describes a statement rejected by an atomic run.
+func atomicOrigin(stmt preprocess.Statement) string {
+	if stmt.Origin == nil {
+		return truncate(stmt.String(), 60)
+	}
+	desc := truncate(stmt.Origin.SQL, 60)
+	if pos := stmt.Origin.Start(); pos.IsValid() {
+		desc = pos.String() + ": " + desc
+	}
+	return desc
+}
+
+// atomically runs fn in the transaction of an atomic run, committed if fn
+// succeeds and rolled back otherwise, even once the run was cancelled.
+func (s *session) atomically(fn func() error, report bool) error {
+	if _, err := s.db.DB.Exec("BEGIN TRANSACTION"); err != nil {
+		return err
+	}
+	s.atomic = true
+	defer func() { s.atomic = false }()
+
+	if err := fn(); err != nil {
+		if _, rerr := s.db.DB.Exec("ROLLBACK"); rerr != nil {
+			return fmt.Errorf("%w\nrollback failed: %v", err, rerr)
+		}
+		if report {
+			fmt.Fprintln(os.Stderr, "Rolled back all statements of the script")
+		}
+		return err
+	}
+	if _, err := s.db.DB.Exec("COMMIT"); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// changesFiles fails in an atomic run, whose rollback would not restore the
+// files what writes or removes.
+func (s *session) changesFiles(what string) error {
+	if s.atomic {
+		return fmt.Errorf("%s changes files, which an atomic run can not roll back", what)
+	}
+	return nil
+}
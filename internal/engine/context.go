@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// A run stops when its context is cancelled, as on Ctrl-C, or when the
+// script or a statement runs out of time: the running DuckDB query is
+// interrupted, the statement fails, and the run ends as on any failure,
+// rolling back its transaction and closing the database.
+
+// Exit statuses of runs stopped before their end: a shell's for a command
+// killed by SIGINT, and timeout(1)'s for a command it stopped.
+const (
+	exitInterrupted = 130
+	exitTimedOut    = 124
+)
+
+// conn runs the statements of a session under the context of the statement
+// being run, so that cancelling it interrupts DuckDB.
+type conn struct {
+	*sql.DB
+	ctx context.Context
+}
+
+func (c *conn) Exec(query string, args ...any) (sql.Result, error) {
+	return c.DB.ExecContext(c.ctx, query, args...)
+}
+
+func (c *conn) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.DB.QueryContext(c.ctx, query, args...)
+}
+
+func (c *conn) QueryRow(query string, args ...any) *sql.Row {
+	return c.DB.QueryRowContext(c.ctx, query, args...)
+}
+
+// Begin starts a transaction, which is rolled back if the context is
+// cancelled before it is committed.
+func (c *conn) Begin() (*sql.Tx, error) {
+	return c.DB.BeginTx(c.ctx, nil)
+}
+
+// txConn runs the statements of a transaction under the context of the
+// statement being run, as conn does outside transactions.
+type txConn struct {
+	*sql.Tx
+	ctx context.Context
+}
+
+func (c *txConn) Exec(query string, args ...any) (sql.Result, error) {
+	return c.Tx.ExecContext(c.ctx, query, args...)
+}
+
+func (c *txConn) QueryRow(query string, args ...any) *sql.Row {
+	return c.Tx.QueryRowContext(c.ctx, query, args...)
+}
+
+// statementContext returns the context of a statement of the run, which
+// expires after the statement timeout, if any.
+func (s *session) statementContext() (context.Context, context.CancelFunc) {
+	if s.statementTimeout > 0 {
+		return context.WithTimeout(s.run, s.statementTimeout)
+	}
+	return context.WithCancel(s.run)
+}
+
+// stopped returns the error of a statement run or about to run when its
+// context was done, naming the statement and why it was stopped, or nil if
+// the context is not done.
+func (s *session) stopped(stmt preprocess.Statement, err error) *StatementError {
+	cause := s.db.ctx.Err()
+	if cause == nil {
+		return nil
+	}
+	e := &StatementError{Err: err, Raw: s.rawErrors && err != nil}
+	if err == nil {
+		e.Err = cause
+	}
+	name := truncate(stmt.String(), 60)
+	if stmt.Origin != nil {
+		e.Position = stmt.Origin.Start()
+		name = truncate(stmt.Origin.SQL, 60)
+	}
+	switch {
+	case !errors.Is(cause, context.DeadlineExceeded):
+		e.Class, e.Message = Interrupted, "Interrupted in statement: "+name
+	case s.run.Err() != nil:
+		e.Class, e.Message = TimedOut, fmt.Sprintf("Script timed out after %s in statement: %s", s.timeout, name)
+	default:
+		e.Class, e.Message = TimedOut, fmt.Sprintf("Statement timed out after %s: %s", s.statementTimeout, name)
+	}
+	return e
+}
@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// metaSchema is the schema, inside each DuckDB database, holding the Hive
+// metadata hive-duck keeps across runs.
+const metaSchema = "_hive_duck_meta"
+
+// databaseInfo is the Hive metadata of a database that DuckDB does not keep.
+type databaseInfo struct {
+	Comment    string
+	Location   string
+	Properties map[string]string
+}
+
+// databaseInfo reads a database's metadata from the metadata schema of the
+// DuckDB database holding it. Databases created outside hive-duck have none.
+func (s *session) databaseInfo(ref tableRef) (databaseInfo, error) {
+	var info databaseInfo
+	if ok, err := s.metaTableExists(ref.catalog, "databases"); err != nil || !ok {
+		return info, err
+	}
+	var params string
+	err := s.db.QueryRow(fmt.Sprintf(`SELECT comment, location, parameters FROM %s.%s.databases WHERE schema_name = ?`,
+		ident(ref.catalog), metaSchema), ref.schema).Scan(&info.Comment, &info.Location, &params)
+	if err == sql.ErrNoRows {
+		return info, nil
+	} else if err != nil {
+		return info, err
+	}
+	return info, json.Unmarshal([]byte(params), &info.Properties)
+}
+
+// metaTableExists reports whether a DuckDB database has the named table in
+// its metadata schema.
+func (s *session) metaTableExists(catalogName, table string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT count(*) FROM duckdb_tables()
+		WHERE database_name = ? AND schema_name = ? AND table_name = ?`, catalogName, metaSchema, table).Scan(&n)
+	return n > 0, err
+}
+
+// saveDatabaseInfo stores a database's metadata, replacing what was there.
+func (s *session) saveDatabaseInfo(ref tableRef, info databaseInfo) error {
+	params, err := json.Marshal(info.Properties)
+	if err != nil {
+		return err
+	}
+	meta := ident(ref.catalog) + "." + metaSchema
+	return s.inTx(func(tx execer) error {
+		for _, stmt := range []string{
+			"CREATE SCHEMA IF NOT EXISTS " + meta,
+			"CREATE TABLE IF NOT EXISTS " + meta + `.databases (schema_name VARCHAR PRIMARY KEY,
+				comment VARCHAR, location VARCHAR, parameters VARCHAR)`,
+		} {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec("INSERT OR REPLACE INTO "+meta+".databases VALUES (?, ?, ?, ?)",
+			ref.schema, info.Comment, info.Location, string(params))
+		return err
+	})
+}
+
+// databaseDir returns the directory holding a database's tables: its
+// LOCATION, or <warehouse>/<db>.db, with the default database being the
+// warehouse itself.
+func (s *session) databaseDir(ref tableRef) string {
+	if info, err := s.databaseInfo(ref); err == nil && info.Location != "" {
+		return s.warehousePath(info.Location)
+	}
+	if ref.Database == "default" {
+		return s.warehouse
+	}
+	return filepath.Join(s.warehouse, ref.Database+".db")
+}
+
+// useDatabase switches to an attached database.
+func (s *session) useDatabase(c *preprocess.UseDatabase) error {
+	if !s.dbMap.HasDatabase(c.Name) {
+		return fmt.Errorf("database does not exist: %s", c.Name)
+	}
+	_, err := s.db.Exec("USE " + ident(c.Name))
+	return err
+}
+
+// createDatabase creates a Hive database. With a DatabaseMap it is a new
+// DuckDB file in the warehouse directory, or an in-memory database if no
+// warehouse is configured, ATTACHed under the database's name. Otherwise it
+// is a schema of the main database.
+func (s *session) createDatabase(c *preprocess.CreateDatabase) error {
+	if ok, err := s.databaseExists(c.Name); err != nil {
+		return err
+	} else if ok {
+		if c.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("database %s already exists", c.Name)
+	}
+
+	if s.dbMap != nil {
+		path := ":memory:"
+		attach := fmt.Sprintf("ATTACH '' AS %s", ident(c.Name))
+		if s.warehouse != "" {
+			if err := os.MkdirAll(s.warehouse, 0o755); err != nil {
+				return err
+			}
+			path = filepath.Join(s.warehouse, c.Name+".duckdb")
+			attach = fmt.Sprintf("ATTACH %s AS %s", quoteLiteral(path), ident(c.Name))
+		}
+		if _, err := s.db.Exec(attach); err != nil {
+			return err
+		}
+		s.dbMap.AddDatabase(c.Name, path)
+		if s.warehouse != "" {
+			s.warehouseDBs[c.Name] = true
+		}
+	} else if _, err := s.db.Exec("CREATE SCHEMA " + ident(c.Name)); err != nil {
+		return err
+	}
+
+	if c.Comment == "" && c.Location == "" && len(c.Properties) == 0 {
+		return nil
+	}
+	ref, err := s.resolveDatabase(c.Name)
+	if err != nil {
+		return err
+	}
+	return s.saveDatabaseInfo(ref, databaseInfo{Comment: c.Comment, Location: c.Location, Properties: c.Properties})
+}
+
+// dropDatabase drops a Hive database. As in Hive, a database that still
+// holds tables is only dropped with CASCADE. Attached databases are
+// DETACHed; their file is deleted only if hive-duck created it in the
+// warehouse, so files mapped in the config are kept.
+func (s *session) dropDatabase(c *preprocess.DropDatabase) error {
+	if c.Name == "default" {
+		return fmt.Errorf("cannot drop the default database")
+	}
+	if ok, err := s.databaseExists(c.Name); err != nil {
+		return err
+	} else if !ok {
+		if c.IfExists {
+			return nil
+		}
+		return fmt.Errorf("database does not exist: %s", c.Name)
+	}
+	current, err := s.resolveDatabase("")
+	if err != nil {
+		return err
+	}
+	if current.Database == c.Name {
+		return fmt.Errorf("cannot drop the current database %s", c.Name)
+	}
+	ref, err := s.resolveDatabase(c.Name)
+	if err != nil {
+		return err
+	}
+
+	tables, err := s.queryNames(`SELECT table_name FROM duckdb_tables() WHERE database_name = ? AND schema_name = ?
+		UNION ALL SELECT view_name FROM duckdb_views() WHERE database_name = ? AND schema_name = ? AND NOT internal`,
+		ref.catalog, ref.schema, ref.catalog, ref.schema)
+	if err != nil {
+		return err
+	}
+	if len(tables) > 0 && !c.Cascade {
+		return fmt.Errorf("database %s is not empty. One or more tables exist", c.Name)
+	}
+
+	if s.dbMap != nil {
+		if _, err := s.db.Exec("DETACH " + ident(c.Name)); err != nil {
+			return err
+		}
+		path := s.dbMap.GetDatabasePath(c.Name)
+		s.dbMap.RemoveDatabase(c.Name)
+		if s.warehouseDBs[c.Name] {
+			delete(s.warehouseDBs, c.Name)
+			for _, p := range []string{path, path + ".wal"} {
+				if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		}
+	} else {
+		if _, err := s.db.Exec("DROP SCHEMA " + ident(ref.schema) + " CASCADE"); err != nil {
+			return err
+		}
+		if ok, err := s.metaTableExists(ref.catalog, "databases"); err != nil {
+			return err
+		} else if ok {
+			if _, err := s.db.Exec(fmt.Sprintf("DELETE FROM %s.%s.databases WHERE schema_name = ?",
+				ident(ref.catalog), metaSchema), ref.schema); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, t := range s.catalog.Tables(c.Name) {
+		s.catalog.Drop(t.Database, t.Name)
+	}
+	return nil
+}
+
+// alterDatabase updates the stored properties or location of a database.
+// As in Hive, SET LOCATION only affects tables created afterwards.
+func (s *session) alterDatabase(c *preprocess.AlterDatabase) error {
+	if ok, err := s.databaseExists(c.Name); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("database does not exist: %s", c.Name)
+	}
+	ref, err := s.resolveDatabase(c.Name)
+	if err != nil {
+		return err
+	}
+	info, err := s.databaseInfo(ref)
+	if err != nil {
+		return err
+	}
+	if c.Location != "" {
+		info.Location = c.Location
+	}
+	if len(c.Properties) > 0 && info.Properties == nil {
+		info.Properties = make(map[string]string)
+	}
+	for k, v := range c.Properties {
+		info.Properties[k] = v
+	}
+	return s.saveDatabaseInfo(ref, info)
+}
+
+// hiveParameters renders database parameters as Hive prints them,
+// {k1=v1, k2=v2}.
+func hiveParameters(props map[string]string) string {
+	if len(props) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(props))
+	for _, k := range sortedKeys(props) {
+		parts = append(parts, k+"="+props[k])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,649 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// DESCRIBE output follows Hive 3 as beeline shows it: three columns, with
+// section headers and key/value entries leaving unused cells NULL.
+var describeColumns = []string{"col_name", "data_type", "comment"}
+
+// hiveTimeLayout is how the metastore renders CreateTime.
+const hiveTimeLayout = "Mon Jan 02 15:04:05 MST 2006"
+
+// described is a table or view being described.
+type described struct {
+	ref   tableRef
+	table *catalog.Table
+	view  string // definition of a view created directly in DuckDB
+	cols  []catalog.Column
+}
+
+// lookupTable resolves name to an existing table or view. Tables and views
+// created directly in DuckDB get a default catalog entry. It returns nil if
+// nothing by that name exists.
+func (s *session) lookupTable(name string) (*described, error) {
+	ref, err := s.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	var kind string
+	var view sql.NullString
+	err = s.db.QueryRow(`SELECT 'VIEW', sql FROM duckdb_views()
+		WHERE database_name = ? AND schema_name = ? AND lower(view_name) = ?
+		UNION ALL SELECT 'TABLE', NULL FROM duckdb_tables()
+		WHERE database_name = ? AND schema_name = ? AND lower(table_name) = ?`,
+		ref.catalog, ref.schema, ref.Name, ref.catalog, ref.schema, ref.Name).Scan(&kind, &view)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	d := &described{ref: ref}
+	t, ok := s.catalogFor(ref).Table(ref.Database, ref.Name)
+	if !ok {
+		t = &catalog.Table{Database: ref.Database, Name: ref.Name, Type: catalog.ManagedTable}
+		if kind == "VIEW" {
+			t.Type = catalog.VirtualView
+			d.view = viewQuery(view.String)
+		}
+	}
+	d.table = t
+	if d.cols, err = s.hiveColumns(ref, t); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+var viewDefinition = regexp.MustCompile(`(?is)^\s*CREATE\b.*?\bVIEW\s+\S+\s+(?:\([^)]*\)\s*)?AS\s+(.*?)\s*;?\s*$`)
+
+// viewQuery extracts the query from a CREATE VIEW statement.
+func viewQuery(stmt string) string {
+	if m := viewDefinition.FindStringSubmatch(stmt); m != nil {
+		return m[1]
+	}
+	return stmt
+}
+
+// hiveColumns returns the data columns of a table with Hive types, taking
+// declared types and comments from the catalog where known.
+func (s *session) hiveColumns(ref tableRef, t *catalog.Table) ([]catalog.Column, error) {
+	if t.FileBacked() {
+		return t.Columns, nil
+	}
+	duck, err := s.columns(ref)
+	if err != nil {
+		return nil, err
+	}
+	declared := make(map[string]catalog.Column, len(t.Columns))
+	for _, c := range t.Columns {
+		declared[strings.ToLower(c.Name)] = c
+	}
+	var cols []catalog.Column
+	for _, c := range duck {
+		if t.IsPartitionKey(c.Name) {
+			continue
+		}
+		if d, ok := declared[strings.ToLower(c.Name)]; ok {
+			cols = append(cols, d)
+			continue
+		}
+		cols = append(cols, catalog.Column{Name: strings.ToLower(c.Name), Type: catalog.HiveType(c.Type), Comment: c.Comment})
+	}
+	return cols, nil
+}
+
+// describeTarget finds the table of a DESCRIBE and the column, if any. A
+// dotted name is a table if one exists by that name, else table.column.
+func (s *session) describeTarget(c *preprocess.Describe) (*described, string, error) {
+	d, err := s.lookupTable(c.Table)
+	if err != nil {
+		return nil, "", err
+	}
+	column := c.Column
+	if d == nil && column == "" {
+		if i := strings.LastIndex(c.Table, "."); i > 0 {
+			column = c.Table[i+1:]
+			if d, err = s.lookupTable(c.Table[:i]); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if d == nil {
+		return nil, "", fmt.Errorf("table not found: %s", c.Table)
+	}
+	return d, column, nil
+}
+
+func (s *session) describe(c *preprocess.Describe) error {
+	d, column, err := s.describeTarget(c)
+	if err != nil {
+		return err
+	}
+	t := d.table
+
+	var values []string
+	if len(c.Partition) > 0 {
+		if len(t.PartitionKeys) == 0 {
+			return fmt.Errorf("table %s is not a partitioned table", d.ref)
+		}
+		if values, err = specValues(t, c.Partition); err != nil {
+			return err
+		}
+		all, err := s.partitions(d.ref, t)
+		if err != nil {
+			return err
+		}
+		if !containsValues(all, values) {
+			return fmt.Errorf("partition not found: %s %s", d.ref, c.Partition)
+		}
+	}
+
+	if column != "" {
+		return s.describeColumn(d, column, c)
+	}
+
+	var rows [][]any
+	switch c.Mode {
+	case "FORMATTED":
+		rows = append(rows, []any{"# col_name", "data_type", "comment"})
+		rows = append(rows, columnRows(t, d.cols)...)
+		rows = append(rows, blankRow())
+		if values != nil {
+			detail, err := s.partitionDetail(d, values)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, detail...)
+		} else {
+			detail, err := s.tableDetail(d)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, detail...)
+		}
+		rows = append(rows, blankRow())
+		rows = append(rows, storageRows(d)...)
+		if d.view != "" {
+			rows = append(rows, blankRow(),
+				[]any{"# View Information", nil, nil},
+				[]any{"Original Query:", d.view, nil},
+				[]any{"Expanded Query:", d.view, nil})
+		}
+		if t.Materialized != nil {
+			rows = append(rows, blankRow())
+			rows = append(rows, materializedRows(t)...)
+		}
+	case "EXTENDED":
+		rows = columnRows(t, d.cols)
+		rows = append(rows, blankRow())
+		if values != nil {
+			params, err := s.partitionParams(d, values)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, []any{"Detailed Partition Information", partitionThrift(d, values, s.partitionLocation(d, values), params), ""})
+		} else {
+			params, err := s.tableParams(d)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, []any{"Detailed Table Information", s.tableThrift(d, s.location(d), params), ""})
+		}
+	default:
+		rows = columnRows(t, d.cols)
+	}
+	return s.printValues(describeColumns, rows)
+}
+
+// columnRows lists the data columns followed, for partitioned tables, by the
+// "# Partition Information" section.
+func columnRows(t *catalog.Table, cols []catalog.Column) [][]any {
+	var rows [][]any
+	for _, c := range cols {
+		rows = append(rows, []any{c.Name, c.Type, c.Comment})
+	}
+	if len(t.PartitionKeys) > 0 {
+		rows = append(rows, blankRow(),
+			[]any{"# Partition Information", nil, nil},
+			[]any{"# col_name", "data_type", "comment"})
+		for _, k := range t.PartitionKeys {
+			rows = append(rows, []any{k.Name, k.Type, k.Comment})
+		}
+	}
+	return rows
+}
+
+func blankRow() []any {
+	return []any{"", nil, nil}
+}
+
+// propertyRows renders a parameter map as sorted ("", key, value) rows.
+func propertyRows(params map[string]string) [][]any {
+	var rows [][]any
+	for _, k := range sortedKeys(params) {
+		rows = append(rows, []any{"", k, params[k]})
+	}
+	return rows
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *session) tableDetail(d *described) ([][]any, error) {
+	t := d.table
+	params, err := s.tableParams(d)
+	if err != nil {
+		return nil, err
+	}
+	rows := [][]any{
+		{"# Detailed Table Information", nil, nil},
+		{"Database:", d.ref.Database, nil},
+		{"OwnerType:", "USER", nil},
+		{"Owner:", s.owner(), nil},
+		{"CreateTime:", createTime(t), nil},
+		{"LastAccessTime:", "UNKNOWN", nil},
+		{"Retention:", "0", nil},
+	}
+	if t.Type != catalog.VirtualView {
+		rows = append(rows, []any{"Location:", s.location(d), nil})
+	}
+	rows = append(rows, []any{"Table Type:", t.Type, nil}, []any{"Table Parameters:", nil, nil})
+	return append(rows, propertyRows(params)...), nil
+}
+
+func (s *session) partitionDetail(d *described, values []string) ([][]any, error) {
+	params, err := s.partitionParams(d, values)
+	if err != nil {
+		return nil, err
+	}
+	rows := [][]any{
+		{"# Detailed Partition Information", nil, nil},
+		{"Partition Value:", "[" + strings.Join(values, ", ") + "]", nil},
+		{"Database:", d.ref.Database, nil},
+		{"Table:", d.ref.Name, nil},
+		{"CreateTime:", "UNKNOWN", nil},
+		{"LastAccessTime:", "UNKNOWN", nil},
+	}
+	if loc := s.partitionLocation(d, values); loc != "" {
+		rows = append(rows, []any{"Location:", loc, nil})
+	}
+	rows = append(rows, []any{"Partition Parameters:", nil, nil})
+	return append(rows, propertyRows(params)...), nil
+}
+
+func storageRows(d *described) [][]any {
+	t := d.table
+	serde, input, output := t.Storage.Classes()
+	if t.Type == catalog.VirtualView {
+		serde = "null"
+	}
+	buckets, bucketCols, sortCols := "-1", "[]", "[]"
+	if t.Buckets != nil {
+		buckets = strconv.Itoa(t.Buckets.Count)
+		bucketCols = "[" + strings.Join(t.Buckets.Columns, ", ") + "]"
+		sortCols = "[" + strings.Join(sortOrders(t.Buckets.SortBy), ", ") + "]"
+	}
+	rows := [][]any{
+		{"# Storage Information", nil, nil},
+		{"SerDe Library:", serde, nil},
+		{"InputFormat:", input, nil},
+		{"OutputFormat:", output, nil},
+		{"Compressed:", "No", nil},
+		{"Num Buckets:", buckets, nil},
+		{"Bucket Columns:", bucketCols, nil},
+		{"Sort Columns:", sortCols, nil},
+	}
+	if t.Type == catalog.VirtualView {
+		return rows
+	}
+	rows = append(rows, []any{"Storage Desc Params:", nil, nil})
+	return append(rows, propertyRows(t.Storage.Params())...)
+}
+
+// sortOrders renders SORTED BY columns as the metastore's Order(...) values.
+func sortOrders(sortBy []string) []string {
+	orders := make([]string, len(sortBy))
+	for i, col := range sortBy {
+		fields := strings.Fields(col)
+		order := 1
+		if len(fields) > 1 && strings.EqualFold(fields[1], "DESC") {
+			order = 0
+		}
+		orders[i] = fmt.Sprintf("Order(col:%s, order:%d)", fields[0], order)
+	}
+	return orders
+}
+
+// location returns the location shown for a table: its LOCATION, or its
+// warehouse directory if a warehouse is configured.
+func (s *session) location(d *described) string {
+	t := d.table
+	if t.Location != "" {
+		return t.Location
+	}
+	if t.Type == catalog.VirtualView || s.warehouse == "" {
+		return ""
+	}
+	return fileURI(s.tableDir(d.ref, t))
+}
+
+func (s *session) partitionLocation(d *described, values []string) string {
+	t := d.table
+	if i := t.FindPartition(values); i >= 0 && t.Partitions[i].Location != "" {
+		return t.Partitions[i].Location
+	}
+	if t.Location != "" {
+		return partitionLocation(t, values)
+	}
+	if loc := s.location(d); loc != "" {
+		return loc + "/" + t.PartitionName(values)
+	}
+	return ""
+}
+
+func fileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return "file:" + filepath.ToSlash(path)
+}
+
+// owner returns the owner of the objects the session shows: as in Hive, the
+// user of the user.name system property, which SET system:user.name changes.
+func (s *session) owner() string {
+	if u := s.conf.System["user.name"]; u != "" {
+		return u
+	}
+	return "hive"
+}
+
+func createTime(t *catalog.Table) string {
+	if t.CreateTime.IsZero() {
+		return "UNKNOWN"
+	}
+	return t.CreateTime.Format(hiveTimeLayout)
+}
+
+// tableParams returns the table parameters Hive would show: the declared
+// TBLPROPERTIES plus the ones the metastore maintains.
+func (s *session) tableParams(d *described) (map[string]string, error) {
+	t := d.table
+	params := make(map[string]string, len(t.Properties)+5)
+	for k, v := range t.Properties {
+		params[k] = v
+	}
+	if t.Type == catalog.ExternalTable {
+		params["EXTERNAL"] = "TRUE"
+	}
+	if t.Comment != "" {
+		params["comment"] = t.Comment
+	}
+	if !t.CreateTime.IsZero() {
+		params["transient_lastDdlTime"] = strconv.FormatInt(t.CreateTime.Unix(), 10)
+	}
+	if t.Type == catalog.VirtualView {
+		return params, nil
+	}
+
+	if len(t.PartitionKeys) > 0 {
+		all, err := s.partitions(d.ref, t)
+		if err != nil {
+			return nil, err
+		}
+		params["numPartitions"] = strconv.Itoa(len(all))
+	}
+	if t.FileBacked() {
+		dirs := []string{s.warehousePath(t.Location)}
+		if len(t.PartitionKeys) > 0 {
+			dirs = dirs[:0]
+			for _, p := range t.Partitions {
+				dirs = append(dirs, s.warehousePath(p.Location))
+			}
+		}
+		if err := addFileStats(params, dirs); err != nil {
+			return nil, err
+		}
+		return params, nil
+	}
+	return params, s.addRowCount(params, d.ref, "")
+}
+
+func (s *session) partitionParams(d *described, values []string) (map[string]string, error) {
+	t := d.table
+	params := make(map[string]string)
+	if t.FileBacked() {
+		return params, addFileStats(params, []string{s.warehousePath(s.partitionLocation(d, values))})
+	}
+	spec := make(preprocess.PartitionSpec, len(values))
+	for i, k := range t.PartitionKeys {
+		spec[i] = preprocess.PartitionValue{Key: k.Name, Op: "=", Value: values[i]}
+	}
+	return params, s.addRowCount(params, d.ref, specPredicate(spec))
+}
+
+// addFileStats sets numFiles and totalSize from the data files in dirs.
+func addFileStats(params map[string]string, dirs []string) error {
+	var files int
+	var size int64
+	for _, dir := range dirs {
+		found, err := findDataFiles(dir)
+		if err != nil {
+			return err
+		}
+		for _, f := range found {
+			if info, err := os.Stat(f); err == nil {
+				files++
+				size += info.Size()
+			}
+		}
+	}
+	params["numFiles"] = strconv.Itoa(files)
+	params["totalSize"] = strconv.FormatInt(size, 10)
+	return nil
+}
+
+// addRowCount sets numRows for a table stored in DuckDB.
+func (s *session) addRowCount(params map[string]string, ref tableRef, where string) error {
+	query := "SELECT count(*) FROM " + ref.sql()
+	if where != "" {
+		query += " WHERE " + where
+	}
+	var n int64
+	if err := s.db.QueryRow(query).Scan(&n); err != nil {
+		return err
+	}
+	params["numRows"] = strconv.FormatInt(n, 10)
+	return nil
+}
+
+// describeColumn describes one column. DESCRIBE FORMATTED adds the column
+// statistics Hive would have computed, here computed from the data.
+func (s *session) describeColumn(d *described, name string, c *preprocess.Describe) error {
+	var col *catalog.Column
+	for _, cols := range [][]catalog.Column{d.cols, d.table.PartitionKeys} {
+		for i := range cols {
+			if strings.EqualFold(cols[i].Name, name) {
+				col = &cols[i]
+			}
+		}
+	}
+	if col == nil {
+		return fmt.Errorf("invalid column reference %s in %s", name, d.ref)
+	}
+	if c.Mode != "FORMATTED" {
+		return s.printValues(describeColumns, [][]any{{col.Name, col.Type, col.Comment}})
+	}
+
+	stats, err := s.columnStats(d.ref, *col, specPredicate(c.Partition))
+	if err != nil {
+		return err
+	}
+	comment := col.Comment
+	if comment == "" {
+		comment = "from deserializer"
+	}
+	rows := [][]any{{"col_name", col.Name}, {"data_type", col.Type}}
+	for i, name := range columnStatNames {
+		rows = append(rows, []any{name, stats[i]})
+	}
+	rows = append(rows, []any{"bitVector", ""}, []any{"comment", comment})
+	return s.printValues([]string{"column_property", "value"}, rows)
+}
+
+var columnStatNames = []string{"min", "max", "num_nulls", "distinct_count", "avg_col_len", "max_col_len", "num_trues", "num_falses"}
+
+// columnStats computes the statistics of columnStatNames that apply to the
+// column's type; the others are empty.
+func (s *session) columnStats(ref tableRef, col catalog.Column, where string) ([]string, error) {
+	typ := strings.ToLower(col.Type)
+	if i := strings.IndexAny(typ, "(<"); i >= 0 {
+		typ = typ[:i]
+	}
+	c := ident(col.Name)
+	exprs := []string{"NULL", "NULL", "NULL", "NULL", "NULL", "NULL", "NULL", "NULL"}
+	switch typ {
+	case "array", "map", "struct", "uniontype":
+		return make([]string, len(exprs)), nil
+	case "string", "varchar", "char", "binary":
+		exprs[4] = fmt.Sprintf("avg(octet_length(CAST(%s AS BLOB)))", c)
+		exprs[5] = fmt.Sprintf("max(octet_length(CAST(%s AS BLOB)))", c)
+	case "boolean":
+		exprs[6] = fmt.Sprintf("count(*) FILTER (WHERE %s)", c)
+		exprs[7] = fmt.Sprintf("count(*) FILTER (WHERE NOT %s)", c)
+	default:
+		exprs[0] = fmt.Sprintf("min(%s)", c)
+		exprs[1] = fmt.Sprintf("max(%s)", c)
+	}
+	exprs[2] = fmt.Sprintf("count(*) - count(%s)", c)
+	if typ != "boolean" {
+		exprs[3] = fmt.Sprintf("count(DISTINCT %s)", c)
+	}
+	for i, e := range exprs {
+		exprs[i] = "CAST(" + e + " AS VARCHAR)"
+	}
+
+	query := "SELECT " + strings.Join(exprs, ", ") + " FROM " + ref.sql()
+	if where != "" {
+		query += " WHERE " + where
+	}
+	values := make([]sql.NullString, len(exprs))
+	ptrs := make([]any, len(exprs))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := s.db.QueryRow(query).Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	stats := make([]string, len(values))
+	for i, v := range values {
+		stats[i] = v.String
+	}
+	return stats, nil
+}
+
+// tableThrift renders a table as the metastore's Table object, as
+// DESCRIBE EXTENDED prints it.
+func (s *session) tableThrift(d *described, location string, params map[string]string) string {
+	t := d.table
+	view := "null"
+	if d.view != "" {
+		view = d.view
+	}
+	return fmt.Sprintf("Table(tableName:%s, dbName:%s, owner:%s, createTime:%d, lastAccessTime:0, retention:0, sd:%s, partitionKeys:[%s], parameters:%s, viewOriginalText:%s, viewExpandedText:%s, tableType:%s)",
+		d.ref.Name, d.ref.Database, s.owner(), unixTime(t), storageThrift(d, location), fieldSchemas(t.PartitionKeys), thriftMap(params), view, view, t.Type)
+}
+
+func partitionThrift(d *described, values []string, location string, params map[string]string) string {
+	return fmt.Sprintf("Partition(values:[%s], dbName:%s, tableName:%s, createTime:0, lastAccessTime:0, sd:%s, parameters:%s)",
+		strings.Join(values, ", "), d.ref.Database, d.ref.Name, storageThrift(d, location), thriftMap(params))
+}
+
+func storageThrift(d *described, location string) string {
+	t := d.table
+	serde, input, output := t.Storage.Classes()
+	buckets, bucketCols, sortCols := -1, "", ""
+	if t.Buckets != nil {
+		buckets = t.Buckets.Count
+		bucketCols = strings.Join(t.Buckets.Columns, ", ")
+		sortCols = strings.Join(sortOrders(t.Buckets.SortBy), ", ")
+	}
+	if location == "" {
+		location = "null"
+	}
+	return fmt.Sprintf("StorageDescriptor(cols:[%s], location:%s, inputFormat:%s, outputFormat:%s, compressed:false, numBuckets:%d, serdeInfo:SerDeInfo(name:null, serializationLib:%s, parameters:%s), bucketCols:[%s], sortCols:[%s], parameters:{}, storedAsSubDirectories:false)",
+		fieldSchemas(d.cols), location, input, output, buckets, serde, thriftMap(t.Storage.Params()), bucketCols, sortCols)
+}
+
+func fieldSchemas(cols []catalog.Column) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		comment := "null"
+		if c.Comment != "" {
+			comment = c.Comment
+		}
+		parts[i] = fmt.Sprintf("FieldSchema(name:%s, type:%s, comment:%s)", c.Name, c.Type, comment)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func thriftMap(m map[string]string) string {
+	parts := make([]string, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		parts = append(parts, k+"="+m[k])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func unixTime(t *catalog.Table) int64 {
+	if t.CreateTime.IsZero() {
+		return 0
+	}
+	return t.CreateTime.Unix()
+}
+
+// showTblProperties lists a table's parameters, or the value of one.
+func (s *session) showTblProperties(c *preprocess.ShowTblProperties) error {
+	d, err := s.lookupTable(c.Table)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return fmt.Errorf("table not found: %s", c.Table)
+	}
+	params, err := s.tableParams(d)
+	if err != nil {
+		return err
+	}
+
+	cols := []string{"prpt_name", "prpt_value"}
+	if c.Key != "" {
+		value, ok := params[c.Key]
+		if !ok {
+			value = fmt.Sprintf("Table %s does not have property: %s", d.ref, c.Key)
+		}
+		return s.printValues(cols, [][]any{{c.Key, value}})
+	}
+	var rows [][]any
+	for _, k := range sortedKeys(params) {
+		rows = append(rows, []any{k, params[k]})
+	}
+	return s.printValues(cols, rows)
+}
@@ -0,0 +1,380 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+)
+
+// Databases of kind warehouse are local copies of a Hive warehouse
+// directory. Each <db>.db directory becomes an in-memory database and each
+// table directory below it an external table, as if MSCK REPAIR TABLE had
+// run on DDL nobody wrote: partition columns come from key=value
+// subdirectories, and the storage format and columns from the data files.
+
+// discoverWarehouses registers the tables of the databases of kind
+// warehouse. Table directories whose format cannot be read are skipped with
+// a warning.
+func (s *session) discoverWarehouses() error {
+	names := make([]string, 0, len(s.dbMap.Warehouses))
+	for name := range s.dbMap.Warehouses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		root := s.dbMap.Warehouses[name]
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return fmt.Errorf("warehouse %s: %w", name, err)
+		}
+		// Tables directly under the root belong to the entry's own
+		// database, as Hive's default database lives at the warehouse root.
+		dirs := map[string][]string{name: nil}
+		for _, e := range entries {
+			if !e.IsDir() || hiddenFile(e.Name()) {
+				continue
+			}
+			if db, ok := strings.CutSuffix(e.Name(), ".db"); ok {
+				tables, err := subdirs(filepath.Join(root, e.Name()))
+				if err != nil {
+					return err
+				}
+				dirs[strings.ToLower(db)] = tables
+			} else {
+				dirs[name] = append(dirs[name], filepath.Join(root, e.Name()))
+			}
+		}
+
+		dbs := make([]string, 0, len(dirs))
+		for db := range dirs {
+			dbs = append(dbs, db)
+		}
+		sort.Strings(dbs)
+		for _, db := range dbs {
+			tables := dirs[db]
+			if s.dbMap.HasDatabase(db) {
+				return fmt.Errorf("warehouse %s: database %s is already mapped", name, db)
+			}
+			if _, err := s.db.Exec("ATTACH ':memory:' AS " + ident(db)); err != nil {
+				return fmt.Errorf("attach database %q: %w", db, err)
+			}
+			s.dbMap.AddDatabase(db, ":memory:")
+			for _, dir := range tables {
+				ref := tableRef{Database: db, Name: strings.ToLower(filepath.Base(dir)), catalog: db, schema: "main"}
+				if err := s.discoverTable(ref, dir); err != nil {
+					fmt.Fprintf(os.Stderr, "WARNING: skipping %s: %v\n", dir, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// discoverTable registers a table directory as an external table.
+func (s *session) discoverTable(ref tableRef, dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	t := &catalog.Table{
+		Database: ref.Database,
+		Name:     ref.Name,
+		Type:     catalog.ExternalTable,
+		Location: "file://" + abs,
+	}
+
+	keys, parts, err := discoverPartitions(abs)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		t.PartitionKeys = append(t.PartitionKeys, catalog.Column{Name: k, Type: "string"})
+	}
+	t.Partitions = parts
+
+	// The first directory with data decides the format and columns.
+	var files []string
+	dataDirs := []string{abs}
+	if len(keys) > 0 {
+		dataDirs = dataDirs[:0]
+		for _, p := range parts {
+			dataDirs = append(dataDirs, strings.TrimPrefix(p.Location, "file://"))
+		}
+	}
+	for _, d := range dataDirs {
+		if files, err = findDataFiles(d); err != nil {
+			return err
+		} else if len(files) > 0 {
+			break
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no data files")
+	}
+	if err := s.detectStorage(t, files); err != nil {
+		return err
+	}
+
+	if err := s.refreshFileTable(ref, t); err != nil {
+		return err
+	}
+	s.catalog.Put(t)
+
+	// The entry is recorded as stored, as the directory holds all of it.
+	// Writing it to the in-memory database anyway would write there on
+	// every statement, which an atomic run can not do along with writes
+	// to another database.
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	s.stored[catalogKey(t)] = storedTable{ref: ref, definition: string(b)}
+	return nil
+}
+
+// discoverPartitions returns the partition keys of a table directory, taken
+// from the first chain of key=value subdirectories, and its partitions.
+func discoverPartitions(root string) ([]string, []catalog.Partition, error) {
+	var keys []string
+	for dir := root; ; {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		next := ""
+		for _, e := range entries {
+			if k, _, ok := strings.Cut(e.Name(), "="); ok && e.IsDir() {
+				keys = append(keys, strings.ToLower(k))
+				next = filepath.Join(dir, e.Name())
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		dir = next
+	}
+
+	var parts []catalog.Partition
+	var walk func(dir string, values []string) error
+	walk = func(dir string, values []string) error {
+		if len(values) == len(keys) {
+			parts = append(parts, catalog.Partition{Values: append([]string(nil), values...), Location: "file://" + dir})
+			return nil
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			k, v, ok := strings.Cut(e.Name(), "=")
+			if !e.IsDir() || !ok || !strings.EqualFold(k, keys[len(values)]) {
+				continue
+			}
+			if u, err := url.PathUnescape(v); err == nil {
+				v = u
+			}
+			if err := walk(filepath.Join(dir, e.Name()), append(values, v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(keys) > 0 {
+		if err := walk(root, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, parts, nil
+}
+
+// detectStorage sets a discovered table's storage and columns from its data
+// files: by extension, else by content for Hive's extensionless 000000_0
+// files.
+func (s *session) detectStorage(t *catalog.Table, files []string) error {
+	format := ""
+	switch strings.ToLower(filepath.Ext(files[0])) {
+	case ".parquet", ".parq":
+		format = "PARQUET"
+	case ".json", ".jsonl", ".ndjson":
+		format = "JSONFILE"
+	case ".avro":
+		format = "AVRO"
+	case ".orc":
+		format = "ORC"
+	case ".csv":
+		return s.detectCSV(t, files)
+	default:
+		head, err := readHead(files[0], 4096)
+		if err != nil {
+			return err
+		}
+		switch trimmed := bytes.TrimSpace(head); {
+		case bytes.HasPrefix(head, []byte("PAR1")):
+			format = "PARQUET"
+		case bytes.HasPrefix(head, []byte("Obj\x01")):
+			format = "AVRO"
+		case bytes.HasPrefix(head, []byte("ORC")):
+			format = "ORC"
+		case bytes.HasPrefix(trimmed, []byte("{")):
+			format = "JSONFILE"
+		default:
+			return s.detectText(t, files, head)
+		}
+	}
+	if format == "ORC" {
+		return fmt.Errorf("storage format ORC is not supported by DuckDB")
+	}
+
+	t.Storage.Format = format
+	reader := map[string]string{
+		"PARQUET":  "read_parquet(%s, hive_partitioning=false)",
+		"JSONFILE": "read_json(%s, format='newline_delimited', hive_partitioning=false)",
+		"AVRO":     "read_avro(%s)",
+	}[format]
+	cols, err := s.describeQuery(fmt.Sprintf(reader, fileList(files)))
+	if err != nil {
+		return err
+	}
+	t.Columns = cols
+	return nil
+}
+
+// detectCSV reads .csv files with OpenCSVSerde, which reads every column as
+// a string, skipping the header line if DuckDB detects one.
+func (s *session) detectCSV(t *catalog.Table, files []string) error {
+	var header bool
+	var delim string
+	if err := s.db.QueryRow(`SELECT HasHeader, Delimiter FROM sniff_csv(?)`, files[0]).Scan(&header, &delim); err != nil {
+		return err
+	}
+	cols, err := s.describeQuery(fmt.Sprintf("read_csv(%s, delim=%s, header=%t, hive_partitioning=false)", fileList(files), quoteLiteral(delim), header))
+	if err != nil {
+		return err
+	}
+	t.Storage = catalog.Storage{
+		Format:          "TEXTFILE",
+		SerDe:           "org.apache.hadoop.hive.serde2.OpenCSVSerde",
+		SerDeProperties: map[string]string{"separatorChar": delim},
+	}
+	for i := range cols {
+		cols[i].Type = "string"
+		if !header {
+			cols[i].Name = fmt.Sprintf("_c%d", i)
+		}
+	}
+	t.Columns = cols
+	if header {
+		t.Properties = map[string]string{"skip.header.line.count": "1"}
+	}
+	return nil
+}
+
+// detectText reads files as LazySimpleSerDe text, guessing the field
+// delimiter from the first line. The files have no header, so the columns
+// are named _c0, _c1, ... with the types DuckDB infers.
+func (s *session) detectText(t *catalog.Table, files []string, head []byte) error {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	delim := "\x01"
+	for _, d := range []string{"\x01", "\t", "|", ","} {
+		if bytes.Contains(line, []byte(d)) {
+			delim = d
+			break
+		}
+	}
+	cols, err := s.describeQuery(fmt.Sprintf("read_csv(%s, delim=%s, quote='', header=false, nullstr='\\N', hive_partitioning=false)",
+		fileList(files), quoteLiteral(delim)))
+	if err != nil {
+		return err
+	}
+	for i := range cols {
+		cols[i].Name = fmt.Sprintf("_c%d", i)
+	}
+	t.Storage = catalog.Storage{Format: "TEXTFILE"}
+	if delim != "\x01" {
+		t.Storage.FieldDelim = delim
+	}
+	t.Columns = cols
+	return nil
+}
+
+// describeQuery returns the columns of a table function call, with Hive
+// types.
+func (s *session) describeQuery(from string) ([]catalog.Column, error) {
+	rows, err := s.db.Query("DESCRIBE SELECT * FROM " + from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var cols []catalog.Column
+	for rows.Next() {
+		vals := make([]any, len(names))
+		ptrs := make([]any, len(names))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		name, _ := vals[0].(string)
+		typ, _ := vals[1].(string)
+		cols = append(cols, catalog.Column{Name: strings.ToLower(name), Type: catalog.HiveType(typ)})
+	}
+	return cols, rows.Err()
+}
+
+// subdirs returns the visible subdirectories of dir.
+func subdirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && !hiddenFile(e.Name()) {
+			dirs = append(dirs, filepath.Join(dir, e.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// hiddenFile reports whether Hive ignores a file or directory, as it does
+// .hive-staging and _temporary.
+func hiddenFile(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func readHead(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, n)
+	m, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:m], nil
+}
+
+func fileList(files []string) string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = quoteLiteral(f)
+	}
+	return "[" + strings.Join(paths, ", ") + "]"
+}
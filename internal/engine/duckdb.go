@@ -1,15 +1,20 @@
 package engine
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	_ "github.com/marcboeker/go-duckdb"
 
+	"github.com/danieljhkim/hive-duck/internal/catalog"
 	"github.com/danieljhkim/hive-duck/internal/config"
 	"github.com/danieljhkim/hive-duck/internal/output"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
 )
 
 type Runner struct {
@@ -18,9 +23,151 @@ type Runner struct {
 	Silent       bool
 	OutputFormat output.Format
 	DatabaseMap  *config.DatabaseMap // Optional: Hive DB -> DuckDB path mapping
+	Warehouse    string              // Optional: local hive.metastore.warehouse.dir
+
+	// WarehouseTables keeps managed PARQUET tables as files in Warehouse.
+	WarehouseTables bool
+
+	// Config holds the conf values and variables the session starts with,
+	// which SET statements change. It is not changed itself.
+	Config *config.Config
+
+	// Verbose echoes each Hive statement on stderr before running it, and
+	// reports how SET statements are applied; from 2 on, the statements
+	// it was rewritten to are echoed too.
+	Verbose int
+
+	// RawErrors appends DuckDB's own message to the Hive message of a
+	// failed statement.
+	RawErrors bool
+
+	// IgnoreErrors runs the rest of the script after a statement fails, as
+	// hive.cli.errors.ignore, which takes precedence when set.
+	IgnoreErrors bool
+
+	// Timeout limits the time the script runs, and StatementTimeout the
+	// time each statement runs; zero means no limit.
+	Timeout          time.Duration
+	StatementTimeout time.Duration
+
+	// Atomic runs the script in a single transaction, rolled back if a
+	// statement fails.
+	Atomic bool
+}
+
+// Run runs the statements of a script. It stops at the first statement
+// that fails, unless errors are ignored: then the failures are reported as
+// they happen and returned together once the script ran. An atomic run
+// never ignores errors, and no run goes on once ctx is done or the script
+// timed out.
+func (r Runner) Run(ctx context.Context, stmts []preprocess.Statement) error {
+	var hoisted []preprocess.Statement
+	if r.Atomic {
+		var err error
+		if hoisted, stmts, err = planAtomic(stmts); err != nil {
+			return err
+		}
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	s, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	if !r.Atomic {
+		return r.runStatements(s, stmts)
+	}
+	if err := r.runStatements(s, hoisted); err != nil {
+		return err
+	}
+	return s.atomically(func() error { return r.runStatements(s, stmts) }, !r.Silent)
+}
+
+// runStatements runs statements in the session, as Run.
+func (r Runner) runStatements(s *session, stmts []preprocess.Statement) error {
+	var failures Failures
+	for _, group := range statementGroups(stmts) {
+		if err := s.runGroup(group, !r.Silent); err != nil {
+			if r.Atomic || s.run.Err() != nil || !s.confBool(ignoreErrorsKey, r.IgnoreErrors) {
+				if len(failures) > 0 {
+					return append(failures, err)
+				}
+				return err
+			}
+			fmt.Fprintln(os.Stderr, err)
+			failures = append(failures, err)
+		}
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
 }
 
-func (r Runner) Run(stmts []string) error {
+// statementGroups groups the statements rewritten from one Hive statement.
+func statementGroups(stmts []preprocess.Statement) [][]preprocess.Statement {
+	var groups [][]preprocess.Statement
+	for i, stmt := range stmts {
+		if i == 0 || stmt.Origin == nil || stmt.Origin != stmts[i-1].Origin {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], stmt)
+	}
+	return groups
+}
+
+// runGroup runs the statements rewritten from one Hive statement, which are
+// reported as one. The first that fails ends the group.
+func (s *session) runGroup(group []preprocess.Statement, report bool) *StatementError {
+	ctx, cancel := s.statementContext()
+	defer cancel()
+	s.db.ctx = ctx
+	defer func() { s.db.ctx = s.run }()
+	if ctx.Err() != nil {
+		// Stopped between statements.
+		return s.located(group[0], nil)
+	}
+
+	start := time.Now()
+	s.beginStatus(report && reportsStatus(group[0]))
+	s.echoOrigin(group[0])
+	for _, stmt := range group {
+		s.echoRewritten(stmt)
+		if err := s.runSQL(stmt.SQL); err != nil {
+			return s.located(stmt, err)
+		}
+		if stmt.Command != nil {
+			if err := s.execCommand(stmt.Command); err != nil {
+				return s.located(stmt, fmt.Errorf("%s failed: %w", stmt.Command, err))
+			}
+			if err := s.syncCatalog(); err != nil {
+				return s.located(stmt, err)
+			}
+		}
+	}
+	s.endStatus(time.Since(start))
+	return nil
+}
+
+// Catalog returns the stored catalog entries of the attached databases.
+func (r Runner) Catalog() ([]*catalog.Table, error) {
+	s, err := r.open(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer s.close()
+	return s.catalog.All(), nil
+}
+
+// open connects to DuckDB, loads extensions, ATTACHes the mapped databases
+// and loads their catalog.
+func (r Runner) open(ctx context.Context) (*session, error) {
 	// go-duckdb uses empty string for in-memory database, not ":memory:"
 	dsn := r.DBPath
 	if dsn == ":memory:" {
@@ -29,60 +176,118 @@ func (r Runner) Run(stmts []string) error {
 
 	db, err := sql.Open("duckdb", dsn)
 	if err != nil {
-		return fmt.Errorf("open duckdb: %w", err)
+		return nil, fmt.Errorf("open duckdb: %w", err)
 	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			log.Printf("Failed to close database: %v", err)
+	// USE and search_path are per-connection state in DuckDB; keep every
+	// statement on the same connection.
+	db.SetMaxOpenConns(1)
+
+	s := &session{
+		db:               &conn{DB: db, ctx: ctx},
+		catalog:          catalog.New(),
+		dbMap:            r.DatabaseMap,
+		warehouse:        r.Warehouse,
+		format:           r.OutputFormat,
+		warehouseTables:  r.WarehouseTables,
+		warehouseDBs:     make(map[string]bool),
+		stored:           make(map[string]storedTable),
+		temps:            catalog.New(),
+		conf:             r.Config,
+		settings:         r.DatabaseMap.Settings(),
+		applied:          make(map[string]bool),
+		verbose:          r.Verbose,
+		rawErrors:        r.RawErrors,
+		run:              ctx,
+		timeout:          r.Timeout,
+		statementTimeout: r.StatementTimeout,
+	}
+	if s.conf == nil {
+		if s.conf, err = config.FromFlags(nil, nil); err != nil {
+			return nil, err
 		}
-	}()
+	}
+	s.conf = s.conf.Clone()
+	if err := r.setup(s); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
 
+func (r Runner) setup(s *session) error {
 	// Extensions
 	for _, ext := range r.Exts {
-		if err := exec(db, fmt.Sprintf("INSTALL %s", ident(ext))); err != nil {
+		if err := exec(s.db.DB, fmt.Sprintf("INSTALL %s", ident(ext))); err != nil {
 			return fmt.Errorf("install ext %q: %w", ext, err)
 		}
-		if err := exec(db, fmt.Sprintf("LOAD %s", ident(ext))); err != nil {
+		if err := exec(s.db.DB, fmt.Sprintf("LOAD %s", ident(ext))); err != nil {
 			return fmt.Errorf("load ext %q: %w", ext, err)
 		}
 	}
 
 	// ATTACH mapped databases
 	if r.DatabaseMap != nil {
-		if err := r.attachDatabases(db); err != nil {
+		if err := r.attachDatabases(s.db.DB, s.warehouseDBs); err != nil {
+			return err
+		}
+		if err := s.discoverWarehouses(); err != nil {
 			return err
 		}
 	}
+	if err := s.loadCatalog(); err != nil {
+		return err
+	}
+	return s.applyConf()
+}
 
-	for _, stmt := range stmts {
-		trim := strings.TrimSpace(stmt)
-		if trim == "" {
-			continue
-		}
+// runSQL executes a plain SQL statement, printing its rows if it returns any.
+func (s *session) runSQL(stmt string) error {
+	query := strings.TrimSpace(stmt)
+	if query == "" {
+		return nil
+	}
+	trim := s.qualifyTemporary(query)
 
-		// Heuristic: print results if it looks like it returns rows
-		if returnsRows(trim) {
-			rows, err := db.Query(trim)
-			if err != nil {
-				return fmt.Errorf("query failed: %w\nSQL: %s", err, trim)
-			}
-			if err := output.PrintRows(rows, r.OutputFormat); err != nil {
-				_ = rows.Close()
-				return err
-			}
-			_ = rows.Close()
-			continue
+	// Heuristic: print results if it looks like it returns rows
+	if returnsRows(trim) {
+		rows, err := s.db.Query(trim)
+		if err != nil {
+			return fmt.Errorf("query failed: %w\nSQL: %s", err, trim)
 		}
+		defer rows.Close()
+		return s.printRows(rows, query)
+	}
 
-		if err := exec(db, trim); err != nil {
-			return fmt.Errorf("exec failed: %w\nSQL: %s", err, trim)
+	res, err := s.db.Exec(trim)
+	if err != nil {
+		return fmt.Errorf("exec failed: %w\nSQL: %s", err, trim)
+	}
+	if verb := dmlVerb(trim); verb != "" {
+		if n, err := res.RowsAffected(); err == nil {
+			s.wrote(verb, n)
 		}
 	}
 	return nil
 }
 
 // attachDatabases ATTACHes all mapped databases so they're available for cross-db queries.
-func (r Runner) attachDatabases(db *sql.DB) error {
+// Databases created by CREATE DATABASE in earlier runs, <warehouse>/<db>.duckdb,
+// are mapped and ATTACHed too, and recorded in warehouseDBs.
+func (r Runner) attachDatabases(db *sql.DB, warehouseDBs map[string]bool) error {
+	if r.Warehouse != "" {
+		files, err := filepath.Glob(filepath.Join(r.Warehouse, "*.duckdb"))
+		if err != nil {
+			return err
+		}
+		for _, path := range files {
+			name := strings.TrimSuffix(filepath.Base(path), ".duckdb")
+			if !r.DatabaseMap.HasDatabase(name) {
+				r.DatabaseMap.AddDatabase(name, path)
+				warehouseDBs[name] = true
+			}
+		}
+	}
+
 	for name, path := range r.DatabaseMap.Databases {
 		var attachSQL string
 		if path == ":memory:" {
@@ -122,8 +327,30 @@ func returnsRows(stmt string) bool {
 		strings.HasPrefix(s, "pragma")
 }
 
+// reservedWords are DuckDB's reserved keywords, which must be quoted when
+// used as identifiers. Hive's "default" database is the common case.
+var reservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "asymmetric": true, "both": true, "case": true, "cast": true,
+	"check": true, "collate": true, "column": true, "constraint": true, "create": true,
+	"default": true, "deferrable": true, "desc": true, "describe": true, "distinct": true,
+	"do": true, "else": true, "end": true, "except": true, "false": true, "fetch": true,
+	"for": true, "foreign": true, "from": true, "grant": true, "group": true, "having": true,
+	"in": true, "initially": true, "intersect": true, "into": true, "lateral": true,
+	"leading": true, "limit": true, "not": true, "null": true, "offset": true, "on": true,
+	"only": true, "or": true, "order": true, "pivot": true, "pivot_longer": true,
+	"pivot_wider": true, "placing": true, "primary": true, "qualify": true,
+	"references": true, "returning": true, "select": true, "show": true, "some": true,
+	"summarize": true, "symmetric": true, "table": true, "then": true, "to": true,
+	"trailing": true, "true": true, "union": true, "unique": true, "unpivot": true,
+	"using": true, "variadic": true, "when": true, "where": true, "window": true, "with": true,
+}
+
 // ident validates/quotes an identifier for INSTALL/LOAD/ATTACH statements.
 func ident(s string) string {
+	if reservedWords[strings.ToLower(s)] {
+		return `"` + s + `"`
+	}
 	// DuckDB identifiers are typically simple; keep it strict.
 	for _, ch := range s {
 		if ch != '_' && (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') && (ch < '0' || ch > '9') {
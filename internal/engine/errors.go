@@ -0,0 +1,242 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Statement errors are reported as the Hive CLI reports them, so that tools
+// parsing Hive's output understand hive-duck's too:
+//
+//	FAILED: SemanticException [Error 10001]: Line 1:14 Table not found 'x'
+//	FAILED: ParseException line 1:9 syntax error at or near "FORM"
+//	FAILED: Execution Error, return code 2 from org.apache.hadoop.hive.ql.exec.mr.MapRedTask. ...
+//
+// The place of the failed statement in the script follows on a line of its
+// own, leaving the error line as Hive prints it.
+//
+// The process exits with the status the Hive CLI would: the Hive error code
+// modulo 256 for compile errors, such as 17 for Table not found and 64 for
+// parse errors, and the return code for execution errors.
+
+// Hive error classes.
+const (
+	ParseException    = "ParseException"
+	SemanticException = "SemanticException"
+	ExecutionError    = "Execution Error"
+
+	// Statements stopped by hive-duck, which Hive has no class for.
+	Interrupted = "Interrupted"
+	TimedOut    = "TimedOut"
+)
+
+// Hive error codes.
+const (
+	errTableNotFound    = 10001
+	errInvalidColumn    = 10004
+	errInvalidFunction  = 10011
+	errWrongArguments   = 10014
+	errDatabaseNotFound = 10072
+	errGeneric          = 40000
+)
+
+// Hive tasks execution errors are reported from.
+const (
+	ddlTask    = "org.apache.hadoop.hive.ql.exec.DDLTask"
+	moveTask   = "org.apache.hadoop.hive.ql.exec.MoveTask"
+	mapRedTask = "org.apache.hadoop.hive.ql.exec.mr.MapRedTask"
+)
+
+// StatementError is the error of a statement of a script, classified as the
+// Hive error it corresponds to.
+type StatementError struct {
+	Position preprocess.Position // where in the script, if known
+
+	Class   string // ParseException, SemanticException, ExecutionError, Interrupted or TimedOut; empty if unclassified
+	Code    int    // Hive error code, or the return code of an ExecutionError
+	Task    string // the Hive task an ExecutionError is reported from
+	Message string
+
+	// Line and Col locate the error in the statement, as Hive does: lines
+	// from 1 and columns from 0. Line is 0 if the place is not known.
+	Line, Col int
+
+	// Err is the error as hive-duck got it, with DuckDB's own message.
+	Err error
+
+	// Raw appends Err to the Hive message.
+	Raw bool
+}
+
+func (e *StatementError) Error() string {
+	msg := e.hive()
+	if e.Position.IsValid() {
+		// On a line of its own, so that the error line starts with
+		// FAILED: as Hive's does.
+		msg += "\n  at " + e.Position.String()
+	}
+	if e.Raw {
+		msg += "\n" + e.Err.Error()
+	}
+	return msg
+}
+
+// hive returns the error as the Hive CLI prints it.
+func (e *StatementError) hive() string {
+	var msg string
+	switch e.Class {
+	case ParseException:
+		msg = "FAILED: ParseException " + e.at("line") + e.Message
+	case SemanticException:
+		if e.Code == errGeneric {
+			msg = "FAILED: SemanticException " + e.at("Line") + e.Message
+		} else {
+			msg = fmt.Sprintf("FAILED: SemanticException [Error %d]: %s%s", e.Code, e.at("Line"), e.Message)
+		}
+	case ExecutionError:
+		msg = fmt.Sprintf("FAILED: Execution Error, return code %d from %s. %s", e.Code, e.Task, e.Message)
+	default:
+		msg = "FAILED: " + e.Message
+	}
+	return msg
+}
+
+func (e *StatementError) Unwrap() error { return e.Err }
+
+// ExitCode returns the exit status of the Hive CLI for the error.
+func (e *StatementError) ExitCode() int {
+	switch e.Class {
+	case ParseException, SemanticException:
+		return e.Code % 256
+	case ExecutionError:
+		return e.Code
+	case Interrupted:
+		return exitInterrupted
+	case TimedOut:
+		return exitTimedOut
+	}
+	return 1
+}
+
+// at renders the place of the error in the statement, if known.
+func (e *StatementError) at(word string) string {
+	if e.Line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %d:%d ", word, e.Line, e.Col)
+}
+
+// Failures are the statements that failed in a script run with errors
+// ignored.
+type Failures []*StatementError
+
+// ignoreErrorsKey is the conf key that keeps a script running after a
+// statement fails.
+const ignoreErrorsKey = "hive.cli.errors.ignore"
+
+func (f Failures) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d statement(s) failed:", len(f))
+	for _, e := range f {
+		// Only the Hive message, without the DuckDB one.
+		msg := e.hive()
+		if e.Position.IsValid() {
+			msg = e.Position.String() + ": " + msg
+		}
+		b.WriteString("\n  " + msg)
+	}
+	return b.String()
+}
+
+// ExitCode returns the exit status for the last failure, as the Hive CLI
+// does.
+func (f Failures) ExitCode() int {
+	return f[len(f)-1].ExitCode()
+}
+
+var (
+	tableNotFoundPattern    = regexp.MustCompile(`^Table with name (\S+) does not exist`)
+	databaseNotFoundPattern = regexp.MustCompile(`^(?:Schema|Catalog) with name (\S+) does not exist`)
+	functionNotFoundPattern = regexp.MustCompile(`^(?:Scalar|Aggregate|Table) Function with name (\S+) does not exist`)
+	columnNotFoundPattern   = regexp.MustCompile(`^Referenced column "?([^"\s]+)"? not found|does not have a column named "([^"]+)"`)
+
+	// hive-duck's own errors for missing tables and databases.
+	ownTableNotFoundPattern    = regexp.MustCompile(`table not found: (\S+)`)
+	ownDatabaseNotFoundPattern = regexp.MustCompile(`database does not exist: (\S+)`)
+)
+
+// classify returns the Hive error a statement error corresponds to.
+func classify(err error) *StatementError {
+	e := &StatementError{Err: err}
+	var de *duckdb.Error
+	if !errors.As(err, &de) {
+		msg := err.Error()
+		switch {
+		case ownTableNotFoundPattern.MatchString(msg):
+			e.semantic(errTableNotFound, "Table not found '%s'", ownTableNotFoundPattern.FindStringSubmatch(msg)[1])
+		case ownDatabaseNotFoundPattern.MatchString(msg):
+			e.semantic(errDatabaseNotFound, "Database does not exist: %s", ownDatabaseNotFoundPattern.FindStringSubmatch(msg)[1])
+		default:
+			e.Message, _, _ = strings.Cut(msg, "\n")
+		}
+		return e
+	}
+
+	// DuckDB messages are "<Type> Error: <message>", followed by hints and
+	// the place of the error on the next lines.
+	msg, _, _ := strings.Cut(de.Msg, "\n")
+	if _, m, ok := strings.Cut(msg, "Error: "); ok {
+		msg = m
+	}
+	switch de.Type {
+	case duckdb.ErrorTypeParser, duckdb.ErrorTypeSyntax:
+		e.Class, e.Code, e.Message = ParseException, errGeneric, msg
+	case duckdb.ErrorTypeCatalog:
+		if m := tableNotFoundPattern.FindStringSubmatch(msg); m != nil {
+			e.semantic(errTableNotFound, "Table not found '%s'", unquote(m[1]))
+		} else if m := databaseNotFoundPattern.FindStringSubmatch(msg); m != nil {
+			e.semantic(errDatabaseNotFound, "Database does not exist: %s", unquote(m[1]))
+		} else if m := functionNotFoundPattern.FindStringSubmatch(msg); m != nil {
+			e.semantic(errInvalidFunction, "Invalid function %s", unquote(m[1]))
+		} else if strings.Contains(msg, "already exists") {
+			e.execution(1, ddlTask, "AlreadyExistsException(message:%s)", msg)
+		} else {
+			e.semantic(errGeneric, "%s", msg)
+		}
+	case duckdb.ErrorTypeBinder:
+		if m := columnNotFoundPattern.FindStringSubmatch(msg); m != nil {
+			e.semantic(errInvalidColumn, "Invalid table alias or column reference '%s'", m[1]+m[2])
+		} else if strings.HasPrefix(msg, "No function matches") {
+			e.semantic(errWrongArguments, "%s", msg)
+		} else {
+			e.semantic(errGeneric, "%s", msg)
+		}
+	case duckdb.ErrorTypeConversion, duckdb.ErrorTypeOutOfRange, duckdb.ErrorTypeInvalidInput,
+		duckdb.ErrorTypeDivideByZero, duckdb.ErrorTypeConstraint:
+		e.execution(2, mapRedTask, "%s", msg)
+	case duckdb.ErrorTypeIO, duckdb.ErrorTypePermission:
+		e.execution(1, moveTask, "%s", msg)
+	default:
+		e.Message = msg
+	}
+	return e
+}
+
+func (e *StatementError) semantic(code int, format string, args ...any) {
+	e.Class, e.Code, e.Message = SemanticException, code, fmt.Sprintf(format, args...)
+}
+
+func (e *StatementError) execution(code int, task, format string, args ...any) {
+	e.Class, e.Code, e.Task, e.Message = ExecutionError, code, task, fmt.Sprintf(format, args...)
+}
+
+// unquote removes the double quotes DuckDB puts around some names.
+func unquote(name string) string {
+	return strings.Trim(name, `"`)
+}
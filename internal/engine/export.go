@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// EXPORT TABLE writes a table's data as Parquet files next to a _metadata
+// file holding its Hive definition, laid out as Hive lays out exports: the
+// files of an unpartitioned table under data/, those of a partitioned table
+// under one ds=x/hr=y directory per partition. IMPORT TABLE reads such a
+// directory back into a new table.
+
+// exportMetadata is the file describing an export.
+const exportMetadata = "_metadata"
+
+// exportFormat versions the layout of the _metadata file.
+const exportFormat = "hive-duck-export/1"
+
+// exported is the content of the _metadata file of an export.
+type exported struct {
+	Format     string              `json:"format"`
+	Table      *catalog.Table      `json:"table"`
+	Partitions []exportedPartition `json:"partitions,omitempty"`
+}
+
+// exportedPartition is one exported partition and its directory, relative
+// to the export.
+type exportedPartition struct {
+	Values []string `json:"values"`
+	Path   string   `json:"path"`
+}
+
+func (s *session) exportTable(c *preprocess.ExportTable) error {
+	d, err := s.lookupTable(c.Table)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return fmt.Errorf("table not found: %s", c.Table)
+	}
+	t := d.table
+	if t.Type == catalog.VirtualView || t.Type == catalog.MaterializedView {
+		return fmt.Errorf("cannot export view %s", d.ref)
+	}
+	if len(c.Partition) > 0 && len(t.PartitionKeys) == 0 {
+		return fmt.Errorf("table %s is not partitioned", d.ref)
+	}
+	if err := checkSpecKeys(t, c.Partition); err != nil {
+		return err
+	}
+
+	// Like Hive, refuse to export into a directory holding other files, but
+	// replace an earlier export so fixtures can be refreshed in place.
+	dir := s.warehousePath(c.Path)
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		if _, err := os.Stat(filepath.Join(dir, exportMetadata)); err != nil {
+			return fmt.Errorf("export target %s is not empty", c.Path)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+
+	meta := exported{Format: exportFormat, Table: exportedTable(d)}
+	if len(t.PartitionKeys) == 0 {
+		if err := s.exportFiles(d, nil, filepath.Join(dir, "data")); err != nil {
+			return err
+		}
+	} else {
+		all, err := s.partitions(d.ref, t)
+		if err != nil {
+			return err
+		}
+		for _, values := range all {
+			if !matchSpec(t, values, c.Partition) {
+				continue
+			}
+			p := exportedPartition{Values: values, Path: t.PartitionName(values)}
+			if err := s.exportFiles(d, values, filepath.Join(dir, filepath.FromSlash(p.Path))); err != nil {
+				return err
+			}
+			meta.Partitions = append(meta.Partitions, p)
+		}
+		if len(c.Partition) > 0 && len(meta.Partitions) == 0 {
+			return fmt.Errorf("partition not found: %s %s", d.ref, c.Partition)
+		}
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, exportMetadata), append(data, '\n'), 0o644)
+}
+
+// exportedTable returns the definition of a table as exported: its Hive
+// columns and metadata, without anything tied to where it is stored.
+func exportedTable(d *described) *catalog.Table {
+	t := *d.table
+	t.Columns = d.cols
+	t.Location, t.Partitions, t.WarehouseFiles = "", nil, false
+	return &t
+}
+
+// exportFiles writes the data columns of one partition, or of the whole
+// table if values is nil, to dir/000000_0.
+func (s *session) exportFiles(d *described, values []string, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	names := make([]string, len(d.cols))
+	for i, col := range d.cols {
+		names[i] = ident(col.Name)
+	}
+	_, err := s.db.Exec(fmt.Sprintf("COPY (SELECT %s FROM %s WHERE %s) TO %s %s",
+		strings.Join(names, ", "), d.ref.sql(), partitionFilter(d.table, values), quoteLiteral(filepath.Join(dir, "000000_0")), s.parquetOptions()))
+	return err
+}
+
+// importTable creates a table from an export and inserts the exported rows.
+// The table is created as a managed table unless EXTERNAL is given; with a
+// LOCATION, external tables keep the imported rows as Parquet files there.
+func (s *session) importTable(c *preprocess.ImportTable) error {
+	dir := s.warehousePath(c.Path)
+	data, err := os.ReadFile(filepath.Join(dir, exportMetadata))
+	if err != nil {
+		return fmt.Errorf("import from %s: %w", c.Path, err)
+	}
+	var meta exported
+	if err := json.Unmarshal(data, &meta); err != nil || meta.Table == nil {
+		return fmt.Errorf("import from %s: invalid %s file", c.Path, exportMetadata)
+	}
+	if meta.Format != exportFormat {
+		return fmt.Errorf("import from %s: unsupported export format %q", c.Path, meta.Format)
+	}
+
+	name := c.Table
+	if name == "" {
+		name = meta.Table.Name
+	}
+	ref, err := s.resolvePermanent(name)
+	if err != nil {
+		return err
+	}
+	if d, err := s.lookupTable(ref.String()); err != nil {
+		return err
+	} else if d != nil {
+		return fmt.Errorf("table already exists: %s", ref)
+	}
+
+	t := *meta.Table
+	if err := checkSpecKeys(&t, c.Partition); err != nil {
+		return err
+	}
+	t.Type, t.Location = catalog.ManagedTable, c.Location
+	if c.External {
+		t.Type = catalog.ExternalTable
+		if t.Location != "" && !writesParquet(&t) {
+			// The imported files are Parquet, whatever the exported table's format.
+			t.Storage = catalog.Storage{Format: "PARQUET"}
+		}
+	}
+	t.WarehouseFiles = s.warehouseTables && t.Type == catalog.ManagedTable && t.Storage.Format == "PARQUET"
+	if !t.FileBacked() {
+		if err := s.createDuckDBTable(ref, &t); err != nil {
+			return err
+		}
+	}
+	if err := s.createTable(&preprocess.CreateTable{Name: ref.String(), Table: &t}); err != nil {
+		return err
+	}
+
+	if len(t.PartitionKeys) == 0 {
+		return s.importFiles(ref, nil, filepath.Join(dir, "data"))
+	}
+	imported := 0
+	for _, p := range meta.Partitions {
+		if !matchSpec(&t, p.Values, c.Partition) {
+			continue
+		}
+		if err := s.importFiles(ref, &t, filepath.Join(dir, filepath.FromSlash(p.Path)), p.Values...); err != nil {
+			return err
+		}
+		imported++
+	}
+	if len(c.Partition) > 0 && imported == 0 {
+		return fmt.Errorf("partition not found in export %s: %s", c.Path, c.Partition)
+	}
+	return nil
+}
+
+// createDuckDBTable creates the DuckDB table of a table stored in DuckDB,
+// with its partition columns last.
+func (s *session) createDuckDBTable(ref tableRef, t *catalog.Table) error {
+	var defs []string
+	for _, col := range append(append([]catalog.Column{}, t.Columns...), t.PartitionKeys...) {
+		typ, err := catalog.DuckDBType(col.Type)
+		if err != nil {
+			return err
+		}
+		defs = append(defs, ident(col.Name)+" "+typ)
+	}
+	_, err := s.db.Exec("CREATE TABLE " + ref.sql() + " (" + strings.Join(defs, ", ") + ")")
+	return err
+}
+
+// importFiles inserts the exported files in dir into a table, into the
+// partition with the given values if it is partitioned.
+func (s *session) importFiles(ref tableRef, t *catalog.Table, dir string, values ...string) error {
+	files, err := findDataFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(files))
+	for i, f := range files {
+		quoted[i] = quoteLiteral(f)
+	}
+	c := &preprocess.Insert{Table: ref.String(), Query: "SELECT * FROM read_parquet([" + strings.Join(quoted, ", ") + "], hive_partitioning = false)"}
+	for i, v := range values {
+		c.Partition = append(c.Partition, preprocess.PartitionValue{Key: t.PartitionKeys[i].Name, Op: "=", Value: v})
+	}
+	return s.insert(c)
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Outcomes of importing one statement of a DDL dump.
+const (
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// DDLStatement is one statement of a DDL dump and where it came from.
+type DDLStatement struct {
+	Source string // e.g. tables/orders.sql:12:1
+	SQL    string
+}
+
+// ImportResult is the outcome of importing one statement of a DDL dump.
+type ImportResult struct {
+	Source string
+	Object string // the table, view or database the statement creates
+	Status string // ImportCreated, ImportSkipped or ImportFailed
+	Reason string
+}
+
+// ImportDDL runs the CREATE statements of a DDL dump, such as the output of
+// SHOW CREATE TABLE for production tables, through the rewrite pipeline.
+// Managed tables are created empty and external tables become views over
+// their (usually missing) locations, with their metadata recorded in the
+// catalog. Objects that already exist are skipped, USE statements switch the
+// database of unqualified names, and any other statement is skipped.
+// Views that fail, typically because they refer to tables defined later in
+// the dump, are retried once the rest is imported. A statement that fails
+// does not stop the import.
+func (r Runner) ImportDDL(stmts []DDLStatement) ([]ImportResult, error) {
+	s, err := r.open(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer s.close()
+
+	opts := &preprocess.RewriteOptions{DatabaseMap: r.DatabaseMap, WarehouseTables: r.WarehouseTables}
+	type view struct {
+		index   int
+		current tableRef // current database when the view was defined
+		sql     string
+	}
+	var results []ImportResult
+	var views []view
+	for _, st := range stmts {
+		res := ImportResult{Source: st.Source}
+		kind, name, ok := preprocess.CreateTarget(st.SQL)
+		if !ok {
+			if db, ok := preprocess.UseTarget(st.SQL); ok {
+				if err := s.runRewritten(st.SQL, opts); err != nil {
+					return results, fmt.Errorf("%s: USE %s: %w", st.Source, db, err)
+				}
+				continue
+			}
+			res.Object = truncate(st.SQL, 40)
+			res.Status, res.Reason = ImportSkipped, "not a CREATE statement"
+			results = append(results, res)
+			continue
+		}
+
+		res.Object = name
+		if kind == "VIEW" {
+			current, err := s.resolveDatabase("")
+			if err != nil {
+				return results, err
+			}
+			views = append(views, view{index: len(results), current: current, sql: st.SQL})
+		}
+		res.Status, res.Reason = s.importStatement(kind, name, st.SQL, opts)
+		results = append(results, res)
+	}
+
+	// Retry the views that failed as long as some view got created.
+	current, err := s.resolveDatabase("")
+	if err != nil {
+		return results, err
+	}
+	for progress := true; progress; {
+		progress = false
+		for _, v := range views {
+			res := &results[v.index]
+			if res.Status != ImportFailed {
+				continue
+			}
+			if err := s.use(v.current); err != nil {
+				return results, err
+			}
+			if res.Status, res.Reason = s.importStatement("VIEW", res.Object, v.sql, opts); res.Status == ImportCreated {
+				progress = true
+			}
+		}
+	}
+	return results, s.use(current)
+}
+
+// use makes ref's database and schema current.
+func (s *session) use(ref tableRef) error {
+	_, err := s.db.Exec("USE " + ident(ref.catalog) + "." + ident(ref.schema))
+	return err
+}
+
+// importStatement imports one CREATE statement and returns its status and
+// the reason it was skipped or failed.
+func (s *session) importStatement(kind, name, stmt string, opts *preprocess.RewriteOptions) (string, string) {
+	reason, err := s.importCheck(kind, name, stmt)
+	if err == nil && reason == "" {
+		if err = s.runRewritten(stmt, opts); err == nil {
+			return ImportCreated, ""
+		}
+	}
+	if err != nil {
+		// DuckDB errors continue with the offending line and a caret.
+		msg, _, _ := strings.Cut(err.Error(), "\n")
+		return ImportFailed, msg
+	}
+	return ImportSkipped, reason
+}
+
+// importCheck returns why a CREATE statement should be skipped, or an error
+// if it cannot be imported.
+func (s *session) importCheck(kind, name, stmt string) (string, error) {
+	if kind == "DATABASE" {
+		if ok, err := s.databaseExists(name); err != nil || ok {
+			return "database already exists", err
+		}
+		return "", nil
+	}
+
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		if ok, err := s.databaseExists(name[:i]); err != nil {
+			return "", err
+		} else if !ok {
+			return "", fmt.Errorf("database does not exist: %s", name[:i])
+		}
+	}
+	if d, err := s.lookupTable(name); err != nil {
+		return "", err
+	} else if d != nil {
+		return strings.ToLower(kind) + " already exists", nil
+	}
+	if kind == "TABLE" {
+		return "", preprocess.CheckCreateTable(stmt)
+	}
+	return "", nil
+}
+
+// runRewritten rewrites a single Hive statement and runs the result without
+// printing anything.
+func (s *session) runRewritten(stmt string, opts *preprocess.RewriteOptions) error {
+	res, err := preprocess.Rewrite([]preprocess.Source{{SQL: stmt}}, opts)
+	if err != nil {
+		return err
+	}
+	for _, st := range res.Statements {
+		if st.SQL != "" {
+			if _, err := s.db.Exec(st.SQL); err != nil {
+				return err
+			}
+		}
+		if st.Command != nil {
+			if err := s.execCommand(st.Command); err != nil {
+				return err
+			}
+			if err := s.syncCatalog(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// truncate shortens a statement to its first line, at most n bytes.
+func truncate(stmt string, n int) string {
+	stmt = strings.TrimSpace(stmt)
+	if i := strings.IndexByte(stmt, '\n'); i >= 0 {
+		stmt = stmt[:i]
+	}
+	if len(stmt) > n {
+		stmt = stmt[:n] + "..."
+	}
+	return stmt
+}
@@ -0,0 +1,348 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Hive INSERT statements are run in two steps: the query result is staged in
+// a temporary table with the target table's columns, then written. Tables
+// stored in DuckDB get the staged rows inserted, after deleting the
+// overwritten partitions; file-backed tables get a new Parquet file per
+// partition, named like Hive's 000000_0, replacing the existing files on
+// INSERT OVERWRITE.
+
+// insertStage is the temporary table holding the rows of an INSERT.
+const insertStage = "temp.main.__hive_duck_insert"
+
+// defaultPartition is the partition of rows whose partition value is NULL.
+const defaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+func (s *session) insert(c *preprocess.Insert) error {
+	qualified := *c
+	qualified.Query = s.qualifyTemporary(c.Query)
+	c = &qualified
+	d, err := s.lookupTable(c.Table)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return fmt.Errorf("table not found: %s", c.Table)
+	}
+	t := d.table
+	if t.Type == catalog.VirtualView || t.Type == catalog.MaterializedView {
+		return fmt.Errorf("cannot insert into view %s", d.ref)
+	}
+
+	// A plain INSERT INTO a DuckDB table needs no emulation.
+	if !t.FileBacked() && !c.Overwrite && len(c.Partition) == 0 {
+		cols := ""
+		if len(c.Columns) > 0 {
+			names := make([]string, len(c.Columns))
+			for i, name := range c.Columns {
+				names[i] = ident(name)
+			}
+			cols = " (" + strings.Join(names, ", ") + ")"
+		}
+		res, err := s.db.Exec("INSERT INTO " + d.ref.sql() + cols + " " + c.Query)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			s.wrote("Loaded", n)
+		}
+		return nil
+	}
+
+	if err := s.stageInsert(d, c); err != nil {
+		return err
+	}
+	defer func() { _, _ = s.db.Exec("DROP TABLE IF EXISTS " + insertStage) }()
+	var n int64
+	if err := s.db.QueryRow("SELECT count(*) FROM " + insertStage).Scan(&n); err != nil {
+		return err
+	}
+	s.wrote("Loaded", n)
+
+	parts, err := s.stagedPartitions(t, c)
+	if err != nil {
+		return err
+	}
+	if t.FileBacked() {
+		return s.writeFiles(d, c.Overwrite, parts)
+	}
+	return s.insertStaged(d, c.Overwrite, parts)
+}
+
+// stageInsert creates the stage table from the query of an INSERT. The
+// query's columns are matched by position to the listed (or all) data
+// columns, followed by the dynamic partition columns; static partition
+// values are constants and unlisted columns are NULL.
+func (s *session) stageInsert(d *described, c *preprocess.Insert) error {
+	t := d.table
+	if len(c.Partition) > 0 && len(t.PartitionKeys) == 0 {
+		return fmt.Errorf("table %s is not partitioned", d.ref)
+	}
+	if err := checkSpecKeys(t, c.Partition); err != nil {
+		return err
+	}
+	static := make(map[string]string)
+	for _, p := range c.Partition {
+		if p.Op != "" {
+			static[strings.ToLower(p.Key)] = p.Value
+		}
+	}
+
+	targets := c.Columns
+	if len(targets) == 0 {
+		for _, col := range d.cols {
+			targets = append(targets, col.Name)
+		}
+	}
+	for _, name := range targets {
+		if columnIndex(d.cols, name) < 0 {
+			return fmt.Errorf("invalid column reference %s in %s", name, d.ref)
+		}
+	}
+	for _, k := range t.PartitionKeys {
+		if _, ok := static[strings.ToLower(k.Name)]; !ok {
+			targets = append(targets, k.Name)
+		}
+	}
+
+	got, err := s.describeQuery("(" + c.Query + ")")
+	if err != nil {
+		return err
+	}
+	if len(got) != len(targets) {
+		return fmt.Errorf("cannot insert into target table because column number/types are different %s: table has %d columns, but query has %d columns",
+			d.ref, len(targets), len(got))
+	}
+
+	aliases := make([]string, len(targets))
+	listed := make(map[string]bool, len(targets))
+	for i, name := range targets {
+		aliases[i] = ident(strings.ToLower(name))
+		listed[strings.ToLower(name)] = true
+	}
+	all := append(append([]catalog.Column{}, d.cols...), t.PartitionKeys...)
+	types, err := s.duckDBTypes(d, all)
+	if err != nil {
+		return err
+	}
+	exprs := make([]string, len(all))
+	for i, col := range all {
+		src := "NULL"
+		if v, ok := static[strings.ToLower(col.Name)]; ok {
+			src = quoteLiteral(v)
+		} else if listed[strings.ToLower(col.Name)] {
+			src = ident(strings.ToLower(col.Name))
+		}
+		exprs[i] = fmt.Sprintf("CAST(%s AS %s) AS %s", src, types[i], ident(col.Name))
+	}
+	_, err = s.db.Exec(fmt.Sprintf("CREATE OR REPLACE TEMP TABLE %s AS SELECT %s FROM (%s) AS __q(%s)",
+		insertStage, strings.Join(exprs, ", "), c.Query, strings.Join(aliases, ", ")))
+	return err
+}
+
+// duckDBTypes returns the DuckDB types of a table's columns: the declared
+// types for file-backed tables, else the types DuckDB stores them with.
+func (s *session) duckDBTypes(d *described, cols []catalog.Column) ([]string, error) {
+	var stored []catalog.Column
+	if !d.table.FileBacked() {
+		var err error
+		if stored, err = s.columns(d.ref); err != nil {
+			return nil, err
+		}
+	}
+	types := make([]string, len(cols))
+	for i, col := range cols {
+		if j := columnIndex(stored, col.Name); j >= 0 {
+			types[i] = stored[j].Type
+			continue
+		}
+		typ, err := catalog.DuckDBType(col.Type)
+		if err != nil {
+			return nil, err
+		}
+		types[i] = typ
+	}
+	return types, nil
+}
+
+// stagedPartitions returns the partitions an INSERT writes: those of the
+// staged rows, plus the static partition even if the query returned no rows,
+// as INSERT OVERWRITE then empties it. An unpartitioned table has a single
+// partition without values.
+func (s *session) stagedPartitions(t *catalog.Table, c *preprocess.Insert) ([][]string, error) {
+	if len(t.PartitionKeys) == 0 {
+		return [][]string{nil}, nil
+	}
+	exprs := make([]string, len(t.PartitionKeys))
+	for i, k := range t.PartitionKeys {
+		exprs[i] = fmt.Sprintf("coalesce(CAST(%s AS VARCHAR), '%s')", ident(k.Name), defaultPartition)
+	}
+	rows, err := s.db.Query("SELECT DISTINCT " + strings.Join(exprs, ", ") + " FROM " + insertStage + " ORDER BY ALL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var parts [][]string
+	for rows.Next() {
+		values := make([]string, len(exprs))
+		ptrs := make([]any, len(exprs))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		parts = append(parts, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if c.Partition.Static() && len(c.Partition) == len(t.PartitionKeys) {
+		values, err := specValues(t, c.Partition)
+		if err != nil {
+			return nil, err
+		}
+		if !containsValues(parts, values) {
+			parts = append(parts, values)
+		}
+	}
+	return parts, nil
+}
+
+// partitionFilter renders a WHERE condition selecting the rows of one
+// partition.
+func partitionFilter(t *catalog.Table, values []string) string {
+	if len(values) == 0 {
+		return "true"
+	}
+	conds := make([]string, len(values))
+	for i, k := range t.PartitionKeys {
+		conds[i] = fmt.Sprintf("coalesce(CAST(%s AS VARCHAR), '%s') = %s", ident(k.Name), defaultPartition, quoteLiteral(values[i]))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// insertStaged inserts the staged rows into a DuckDB table, first deleting
+// the rows of the written partitions for INSERT OVERWRITE.
+func (s *session) insertStaged(d *described, overwrite bool, parts [][]string) error {
+	return s.inTx(func(tx execer) error {
+		if overwrite {
+			for _, values := range parts {
+				if _, err := tx.Exec("DELETE FROM " + d.ref.sql() + " WHERE " + partitionFilter(d.table, values)); err != nil {
+					return err
+				}
+			}
+		}
+		names := make([]string, 0, len(d.cols)+len(d.table.PartitionKeys))
+		for _, col := range append(append([]catalog.Column{}, d.cols...), d.table.PartitionKeys...) {
+			names = append(names, ident(col.Name))
+		}
+		_, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT * FROM %s", d.ref.sql(), strings.Join(names, ", "), insertStage))
+		return err
+	})
+}
+
+// writeFiles writes the staged rows of each partition to a new Parquet file
+// in the partition's directory, registers the partitions and rebuilds the
+// table's view. With overwrite the existing files of the written partitions
+// are deleted once the new ones are in place.
+func (s *session) writeFiles(d *described, overwrite bool, parts [][]string) error {
+	t := d.table
+	if err := s.changesFiles("INSERT into " + d.ref.String()); err != nil {
+		return err
+	}
+	if !writesParquet(t) {
+		return fmt.Errorf("cannot insert into %s: only tables stored as PARQUET can be written", d.ref)
+	}
+	names := make([]string, len(d.cols))
+	for i, col := range d.cols {
+		names[i] = ident(col.Name)
+	}
+
+	for _, values := range parts {
+		loc := t.Location
+		if values != nil {
+			loc = partitionLocation(t, values)
+			if i := t.FindPartition(values); i >= 0 {
+				loc = t.Partitions[i].Location
+			}
+		}
+		dir := s.warehousePath(loc)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+
+		var n int
+		filter := partitionFilter(t, values)
+		if err := s.db.QueryRow("SELECT count(*) FROM " + insertStage + " WHERE " + filter).Scan(&n); err != nil {
+			return err
+		}
+		staging := ""
+		if n > 0 {
+			staging = filepath.Join(dir, ".hive-staging_"+filepath.Base(dir))
+			if _, err := s.db.Exec(fmt.Sprintf("COPY (SELECT %s FROM %s WHERE %s) TO %s %s",
+				strings.Join(names, ", "), insertStage, filter, quoteLiteral(staging), s.parquetOptions())); err != nil {
+				return err
+			}
+		}
+		if overwrite {
+			if err := removeDataFiles(dir); err != nil {
+				return err
+			}
+		}
+		if staging != "" {
+			if err := os.Rename(staging, nextDataFile(dir)); err != nil {
+				return err
+			}
+		}
+		if values != nil {
+			t.AddPartition(catalog.Partition{Values: values, Location: loc})
+		}
+	}
+	return s.refreshFileTable(d.ref, t)
+}
+
+// writesParquet reports whether a table's files are Parquet, the only
+// format INSERT writes.
+func writesParquet(t *catalog.Table) bool {
+	return strings.EqualFold(t.Storage.Format, "PARQUET") ||
+		strings.Contains(strings.ToLower(t.Storage.SerDe), "parquet") ||
+		inputFormatName(t.Storage.InputFormat) == "PARQUET"
+}
+
+// removeDataFiles deletes the data files directly in dir.
+func removeDataFiles(dir string) error {
+	files, err := findDataFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextDataFile returns the path of a new data file in dir, named as Hive
+// names the output of a single task: 000000_0, then 000000_0_copy_1, ...
+func nextDataFile(dir string) string {
+	name := filepath.Join(dir, "000000_0")
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name
+		}
+		name = filepath.Join(dir, fmt.Sprintf("000000_0_copy_%d", i))
+	}
+}
@@ -0,0 +1,479 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// loadData emulates LOAD DATA by reading the input files with the storage
+// format the table was declared with and inserting them into the table.
+// LOCAL paths are read in place; other paths are moved into the table's
+// directory under the warehouse first, as Hive moves HDFS files. For
+// file-backed tables the files themselves become the table's data.
+func (s *session) loadData(c *preprocess.LoadData) error {
+	ref, err := s.resolve(c.Table)
+	if err != nil {
+		return err
+	}
+	cols, err := s.columns(ref)
+	if err != nil {
+		return err
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("table not found: %s", ref)
+	}
+
+	meta, ok := s.catalogFor(ref).Table(ref.Database, ref.Name)
+	if !ok {
+		// Unknown to the catalog: assume Hive's defaults.
+		meta = &catalog.Table{Database: ref.Database, Name: ref.Name, Storage: catalog.Storage{Format: "TEXTFILE"}}
+	}
+	if err := checkPartitionSpec(meta, c.Partition); err != nil {
+		return err
+	}
+
+	if meta.FileBacked() {
+		return s.loadIntoFileTable(c, ref, meta)
+	}
+
+	var dataCols []catalog.Column
+	for _, col := range cols {
+		if !meta.IsPartitionKey(col.Name) {
+			dataCols = append(dataCols, col)
+		}
+	}
+
+	var files []string
+	if c.Local {
+		files, err = listDataFiles(c.Path)
+	} else {
+		files, err = s.moveIntoTable(c, ref, meta)
+	}
+	if err != nil {
+		return err
+	}
+
+	reader, err := readerSQL(files, meta, dataCols)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ref, err)
+	}
+
+	names := make([]string, 0, len(cols))
+	values := make([]string, 0, len(cols))
+	for _, col := range dataCols {
+		names = append(names, ident(col.Name))
+		values = append(values, ident(col.Name))
+	}
+	var where []string
+	for _, p := range c.Partition {
+		names = append(names, ident(p.Key))
+		values = append(values, quoteLiteral(p.Value))
+		where = append(where, fmt.Sprintf("%s = %s", ident(p.Key), quoteLiteral(p.Value)))
+	}
+
+	return s.inTx(func(tx execer) error {
+		if c.Overwrite {
+			del := "DELETE FROM " + ref.sql()
+			if len(where) > 0 {
+				del += " WHERE " + strings.Join(where, " AND ")
+			}
+			if _, err := tx.Exec(del); err != nil {
+				return err
+			}
+		}
+		insert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+			ref.sql(), strings.Join(names, ", "), strings.Join(values, ", "), reader)
+		_, err := tx.Exec(insert)
+		return err
+	})
+}
+
+// checkPartitionSpec verifies that spec names every partition key of the
+// table, as LOAD DATA requires a static partition.
+func checkPartitionSpec(t *catalog.Table, spec preprocess.PartitionSpec) error {
+	if len(t.PartitionKeys) == 0 {
+		if len(spec) > 0 {
+			return fmt.Errorf("table %s.%s is not partitioned", t.Database, t.Name)
+		}
+		return nil
+	}
+	if len(spec) != len(t.PartitionKeys) {
+		return fmt.Errorf("table %s.%s is partitioned by %s; a value is required for each partition column",
+			t.Database, t.Name, partitionKeyNames(t))
+	}
+	for _, p := range spec {
+		if !t.IsPartitionKey(p.Key) {
+			return fmt.Errorf("%s is not a partition column of %s.%s", p.Key, t.Database, t.Name)
+		}
+	}
+	return nil
+}
+
+func partitionKeyNames(t *catalog.Table) string {
+	names := make([]string, len(t.PartitionKeys))
+	for i, c := range t.PartitionKeys {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// readerSQL returns a DuckDB table function call that reads files with the
+// table's storage format, producing the given columns.
+func readerSQL(files []string, t *catalog.Table, cols []catalog.Column) (string, error) {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = quoteLiteral(f)
+	}
+	list := "[" + strings.Join(paths, ", ") + "]"
+
+	st := t.Storage
+	serde := strings.ToLower(st.SerDe)
+	format := strings.ToUpper(st.Format)
+	if format == "" && st.InputFormat != "" {
+		format = inputFormatName(st.InputFormat)
+	}
+
+	switch {
+	case strings.Contains(serde, "opencsvserde"):
+		sep, quote, escape := ",", `"`, `\`
+		if v, ok := st.SerDeProperties["separatorChar"]; ok {
+			sep = v
+		}
+		if v, ok := st.SerDeProperties["quoteChar"]; ok {
+			quote = v
+		}
+		if v, ok := st.SerDeProperties["escapeChar"]; ok {
+			escape = v
+		}
+		return fmt.Sprintf("read_csv(%s, delim=%s, quote=%s, escape=%s, header=false, skip=%d, columns=%s, auto_detect=false)",
+			list, quoteLiteral(sep), quoteLiteral(quote), quoteLiteral(escape), skipLines(t), columnsStruct(cols, true)), nil
+
+	case strings.Contains(serde, "jsonserde") || format == "JSONFILE":
+		return fmt.Sprintf("read_json(%s, format='newline_delimited', columns=%s)", list, columnsStruct(cols, false)), nil
+
+	case strings.Contains(serde, "parquet") || format == "PARQUET":
+		return fmt.Sprintf("read_parquet(%s)", list), nil
+
+	case strings.Contains(serde, "avro") || format == "AVRO":
+		return fmt.Sprintf("read_avro(%s)", list), nil
+
+	case serde == "" || strings.Contains(serde, "lazysimpleserde"):
+		if format != "" && format != "TEXTFILE" {
+			return "", fmt.Errorf("storage format %s is not supported by DuckDB", format)
+		}
+		for _, c := range cols {
+			if isComplexType(c.Type) {
+				return "", fmt.Errorf("column %s: complex types are not supported in text files", c.Name)
+			}
+		}
+		delim, null := "\x01", `\N`
+		if st.FieldDelim != "" {
+			delim = st.FieldDelim
+		}
+		if st.NullFormat != "" {
+			null = st.NullFormat
+		}
+		// Like LazySimpleSerDe, missing trailing fields (as after ADD
+		// COLUMNS) and fields that don't parse as the column type read as
+		// NULL.
+		casts := make([]string, len(cols))
+		for i, c := range cols {
+			casts[i] = fmt.Sprintf("TRY_CAST(%s AS %s) AS %s", ident(c.Name), c.Type, ident(c.Name))
+		}
+		return fmt.Sprintf("(SELECT %s FROM read_csv(%s, delim=%s, quote='', escape=%s, header=false, skip=%d, nullstr=%s, columns=%s, null_padding=true, auto_detect=false))",
+			strings.Join(casts, ", "), list, quoteLiteral(delim), quoteLiteral(st.EscapeDelim), skipLines(t), quoteLiteral(null), columnsStruct(cols, true)), nil
+	}
+	return "", fmt.Errorf("SerDe %s is not supported", st.SerDe)
+}
+
+// inputFormatName derives a STORED AS format name from an INPUTFORMAT class.
+func inputFormatName(class string) string {
+	c := strings.ToLower(class)
+	switch {
+	case strings.Contains(c, "parquet"):
+		return "PARQUET"
+	case strings.Contains(c, "orc"):
+		return "ORC"
+	case strings.Contains(c, "avro"):
+		return "AVRO"
+	case strings.Contains(c, "sequencefile"):
+		return "SEQUENCEFILE"
+	case strings.Contains(c, "textinputformat"):
+		return "TEXTFILE"
+	}
+	return class
+}
+
+// skipLines returns the table's skip.header.line.count property.
+func skipLines(t *catalog.Table) int {
+	var n int
+	_, _ = fmt.Sscan(t.Properties["skip.header.line.count"], &n)
+	return n
+}
+
+// columnsStruct renders a read_csv/read_json columns={...} argument. Hive's
+// OpenCSVSerde reads every column as a string, so allVarchar is set for it.
+func columnsStruct(cols []catalog.Column, allVarchar bool) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		typ := c.Type
+		if allVarchar {
+			typ = "VARCHAR"
+		}
+		parts[i] = quoteLiteral(c.Name) + ": " + quoteLiteral(typ)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func isComplexType(duckType string) bool {
+	t := strings.ToUpper(duckType)
+	return strings.HasSuffix(t, "]") || strings.HasPrefix(t, "STRUCT") || strings.HasPrefix(t, "MAP")
+}
+
+var globChars = regexp.MustCompile(`[*?\[]`)
+
+// listDataFiles expands a LOAD DATA path into data files, failing if there
+// are none.
+func listDataFiles(path string) ([]string, error) {
+	if !globChars.MatchString(path) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("invalid path %s: %w", path, err)
+		}
+	}
+	files, err := findDataFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files matching path %s", path)
+	}
+	return files, nil
+}
+
+// findDataFiles expands a file, directory or glob into data files. A
+// directory means all of its files; hidden files and Hive's _SUCCESS-style
+// markers are skipped. A missing directory has no files.
+func findDataFiles(path string) ([]string, error) {
+	var candidates []string
+	if globChars.MatchString(path) {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, err
+		}
+		candidates = matches
+	} else {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return []string{path}, nil
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			candidates = append(candidates, filepath.Join(path, e.Name()))
+		}
+	}
+
+	var files []string
+	for _, f := range candidates {
+		base := filepath.Base(f)
+		if strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+			continue
+		}
+		if info, err := os.Stat(f); err == nil && info.Mode().IsRegular() {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// moveIntoTable moves the files of a non-LOCAL LOAD DATA into the table (or
+// partition) directory inside the warehouse and returns their new paths.
+func (s *session) moveIntoTable(c *preprocess.LoadData, ref tableRef, t *catalog.Table) ([]string, error) {
+	if s.warehouse == "" {
+		return nil, fmt.Errorf("LOAD DATA INPATH requires a warehouse directory; set hive.metastore.warehouse.dir or use LOAD DATA LOCAL")
+	}
+	if err := s.changesFiles("LOAD DATA INPATH"); err != nil {
+		return nil, err
+	}
+	files, err := listDataFiles(s.warehousePath(c.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	dir := s.tableDir(ref, t)
+	if len(c.Partition) > 0 {
+		dir = filepath.Join(dir, filepath.FromSlash(c.Partition.Path()))
+	}
+	return placeFiles(files, dir, c.Overwrite, true)
+}
+
+// loadIntoFileTable emulates LOAD DATA for a file-backed table: the files
+// are copied (LOCAL) or moved into the table or partition directory, which
+// is then registered and the table's view rebuilt.
+func (s *session) loadIntoFileTable(c *preprocess.LoadData, ref tableRef, t *catalog.Table) error {
+	if err := s.changesFiles("LOAD DATA into " + ref.String()); err != nil {
+		return err
+	}
+	src := c.Path
+	if !c.Local {
+		if s.warehouse == "" {
+			return fmt.Errorf("LOAD DATA INPATH requires a warehouse directory; set hive.metastore.warehouse.dir or use LOAD DATA LOCAL")
+		}
+		src = s.warehousePath(c.Path)
+	}
+	files, err := listDataFiles(src)
+	if err != nil {
+		return err
+	}
+
+	loc := t.Location
+	var values []string
+	if len(c.Partition) > 0 {
+		if values, err = specValues(t, c.Partition); err != nil {
+			return err
+		}
+		loc = partitionLocation(t, values)
+		if i := t.FindPartition(values); i >= 0 {
+			loc = t.Partitions[i].Location
+		}
+	}
+	if _, err := placeFiles(files, s.warehousePath(loc), c.Overwrite, !c.Local); err != nil {
+		return err
+	}
+	if values != nil {
+		t.AddPartition(catalog.Partition{Values: values, Location: loc})
+	}
+	return s.refreshFileTable(ref, t)
+}
+
+// placeFiles moves or copies files into dir, first emptying dir when
+// overwrite is set, and returns the new paths. Files already in dir stay
+// where they are; loading files from inside dir with overwrite fails, as
+// emptying dir would delete them.
+func placeFiles(files []string, dir string, overwrite, move bool) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	inPlace := make(map[string]bool)
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return nil, err
+		}
+		if !inDir(abs, absDir) {
+			continue
+		}
+		if overwrite {
+			return nil, fmt.Errorf("cannot overwrite %s with %s, which is inside it", dir, f)
+		}
+		inPlace[f] = filepath.Dir(abs) == absDir
+	}
+
+	if overwrite {
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	placed := make([]string, 0, len(files))
+	for _, f := range files {
+		dst := filepath.Join(dir, filepath.Base(f))
+		if inPlace[f] {
+			placed = append(placed, dst)
+			continue
+		}
+		var err error
+		if move {
+			err = moveFile(f, dst)
+		} else {
+			err = copyFile(f, dst)
+		}
+		if err != nil {
+			return nil, err
+		}
+		placed = append(placed, dst)
+	}
+	return placed, nil
+}
+
+// tableDir returns the directory holding a table's files: its LOCATION, or
+// <database dir>/<table> as Hive lays out managed tables.
+func (s *session) tableDir(ref tableRef, t *catalog.Table) string {
+	if t != nil && t.Location != "" {
+		return s.warehousePath(t.Location)
+	}
+	return filepath.Join(s.databaseDir(ref), ref.Name)
+}
+
+var uriPrefix = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.\-]*://[^/]*`)
+
+// warehousePath maps a Hive filesystem path (hdfs://nn/user/x, /user/x or
+// user/x) to a local path inside the warehouse directory. Paths that already
+// point inside the warehouse are returned unchanged.
+func (s *session) warehousePath(p string) string {
+	if strings.HasPrefix(p, "file://") {
+		return strings.TrimPrefix(p, "file://")
+	}
+	p = uriPrefix.ReplaceAllString(p, "")
+	if s.warehouse == "" {
+		return p
+	}
+	if abs, err := filepath.Abs(s.warehouse); err == nil && inDir(filepath.Clean(p), abs) {
+		return p
+	}
+	return filepath.Join(s.warehouse, filepath.FromSlash(p))
+}
+
+// inDir reports whether path p is dir or lies below it.
+func inDir(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// moveFile renames src to dst, falling back to copy and delete when they are
+// on different filesystems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
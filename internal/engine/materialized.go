@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Materialized views are DuckDB tables holding the result of their query,
+// with the query kept in the catalog so REBUILD can run it again. The query
+// is run in the database that was current when the view was created, which
+// is how Hive resolves its unqualified names. Queries are never rewritten to
+// use a materialized view; the rewrite flag is only recorded.
+
+func (s *session) createMaterializedView(c *preprocess.CreateMaterializedView) error {
+	ref, err := s.resolvePermanent(c.Name)
+	if err != nil {
+		return err
+	}
+	d, err := s.lookupTable(c.Name)
+	if err != nil {
+		return err
+	}
+	if d != nil {
+		if c.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("table already exists: %s", ref)
+	}
+	current, err := s.resolveDatabase("")
+	if err != nil {
+		return err
+	}
+
+	t := *c.Table
+	mv := *c.Table.Materialized
+	t.PartitionKeys = append([]catalog.Column(nil), c.Table.PartitionKeys...)
+	t.Database, t.Name = ref.Database, ref.Name
+	t.CreateTime = time.Now()
+	mv.Query, mv.CurrentDatabase, mv.RebuildTime = c.Query, current.Database, t.CreateTime
+	t.Materialized = &mv
+	if _, err := s.db.Exec("CREATE TABLE " + ref.sql() + " AS " + materializedQuery(&t)); err != nil {
+		return err
+	}
+
+	// Partition keys take their types from the query's result.
+	cols, err := s.columns(ref)
+	if err != nil {
+		return err
+	}
+	for i, k := range t.PartitionKeys {
+		j := columnIndex(cols, k.Name)
+		if j < 0 {
+			_, _ = s.db.Exec("DROP TABLE " + ref.sql())
+			return fmt.Errorf("partition column %s is not in the query of materialized view %s", k.Name, ref)
+		}
+		t.PartitionKeys[i] = catalog.Column{Name: k.Name, Type: catalog.HiveType(cols[j].Type)}
+	}
+	s.catalog.Put(&t)
+	return nil
+}
+
+// materializedQuery returns the query that fills a materialized view, with
+// the partition columns moved last as Hive stores them.
+func materializedQuery(t *catalog.Table) string {
+	if len(t.PartitionKeys) == 0 {
+		return t.Materialized.Query
+	}
+	keys := make([]string, len(t.PartitionKeys))
+	for i, k := range t.PartitionKeys {
+		keys[i] = ident(k.Name)
+	}
+	return fmt.Sprintf("SELECT * EXCLUDE (%s), %s FROM (%s) AS __mv",
+		strings.Join(keys, ", "), strings.Join(keys, ", "), t.Materialized.Query)
+}
+
+// materializedView looks up the catalog entry of a materialized view.
+func (s *session) materializedView(name string) (tableRef, *catalog.Table, error) {
+	ref, err := s.resolve(name)
+	if err != nil {
+		return ref, nil, err
+	}
+	t, ok := s.catalog.Table(ref.Database, ref.Name)
+	if !ok {
+		return ref, nil, nil
+	}
+	if t.Type != catalog.MaterializedView {
+		return ref, nil, fmt.Errorf("%s is not a materialized view", ref)
+	}
+	return ref, t, nil
+}
+
+func (s *session) alterMaterializedView(c *preprocess.AlterMaterializedView) error {
+	ref, t, err := s.materializedView(c.Name)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return fmt.Errorf("materialized view not found: %s", c.Name)
+	}
+	switch c.Action {
+	case "ENABLE REWRITE":
+		t.Materialized.RewriteEnabled = true
+	case "DISABLE REWRITE":
+		t.Materialized.RewriteEnabled = false
+	default:
+		return s.rebuildMaterializedView(ref, t)
+	}
+	return nil
+}
+
+// rebuildMaterializedView replaces the view's rows with the current result
+// of its query. The old rows are kept if the query fails.
+func (s *session) rebuildMaterializedView(ref tableRef, t *catalog.Table) error {
+	current, err := s.resolveDatabase("")
+	if err != nil {
+		return err
+	}
+	home, err := s.resolveDatabase(t.Materialized.CurrentDatabase)
+	if err != nil {
+		return err
+	}
+	if err := s.use(home); err != nil {
+		return fmt.Errorf("rebuild %s: %w", ref, err)
+	}
+	_, err = s.db.Exec("CREATE OR REPLACE TABLE " + ref.sql() + " AS " + materializedQuery(t))
+	if uerr := s.use(current); err == nil {
+		err = uerr
+	}
+	if err != nil {
+		return err
+	}
+	t.Materialized.RebuildTime = time.Now()
+	return nil
+}
+
+func (s *session) dropMaterializedView(c *preprocess.DropMaterializedView) error {
+	ref, t, err := s.materializedView(c.Name)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		if c.IfExists {
+			return nil
+		}
+		return fmt.Errorf("materialized view not found: %s", c.Name)
+	}
+	if _, err := s.db.Exec("DROP TABLE IF EXISTS " + ref.sql()); err != nil {
+		return err
+	}
+	s.catalog.Drop(ref.Database, ref.Name)
+	return nil
+}
+
+// materializedRows is the "# Materialized View Information" section of
+// DESCRIBE FORMATTED.
+func materializedRows(t *catalog.Table) [][]any {
+	mv := t.Materialized
+	rewrite := "No"
+	if mv.RewriteEnabled {
+		rewrite = "Yes"
+	}
+	return [][]any{
+		{"# Materialized View Information", nil, nil},
+		{"Original Query:", mv.Query, nil},
+		{"Expanded Query:", mv.Query, nil},
+		{"Rewrite Enabled:", rewrite, nil},
+		{"Outdated for Rewriting:", "Unknown", nil},
+		{"Last Rebuild Time:", mv.RebuildTime.Format(hiveTimeLayout), nil},
+	}
+}
@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// MERGE is run as a transaction of DELETE, UPDATE and INSERT statements, as
+// the linked DuckDB has no MERGE. Every clause must see the target as it was
+// before the MERGE, so the rows to insert are staged first and deletes run
+// before updates; each WHEN MATCHED clause excludes the rows claimed by the
+// clauses before it. The source is staged too, so a query source is run
+// once.
+
+const (
+	mergeSource = "temp.main.__hive_duck_merge_source"
+	mergeInsert = "temp.main.__hive_duck_merge_insert"
+)
+
+func (s *session) merge(c *preprocess.Merge) error {
+	d, err := s.lookupTable(c.Target)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return fmt.Errorf("table not found: %s", c.Target)
+	}
+	if t := d.table; t.Type == catalog.VirtualView || t.Type == catalog.MaterializedView {
+		return fmt.Errorf("cannot merge into view %s", d.ref)
+	}
+	if d.table.FileBacked() {
+		return fmt.Errorf("cannot merge into %s: only tables stored in DuckDB can be updated", d.ref)
+	}
+
+	targetAlias, sourceAlias := c.TargetAlias, c.SourceAlias
+	if targetAlias == "" {
+		targetAlias = d.ref.Name
+	}
+	if sourceAlias == "" {
+		parts := strings.Split(c.Source, ".")
+		sourceAlias = parts[len(parts)-1]
+	}
+	target := d.ref.sql() + " AS " + ident(targetAlias)
+	source := mergeSource + " AS " + ident(sourceAlias)
+
+	// References to temporary tables are resolved before the transaction
+	// starts, as resolving them queries the connection it holds.
+	stage := s.qualifyTemporary("SELECT * FROM " + c.Source)
+	on := "(" + s.qualifyTemporary(c.On) + ")"
+	clauses := make([]preprocess.MergeClause, len(c.Clauses))
+	for i, cl := range c.Clauses {
+		clauses[i] = s.qualifyMergeClause(cl)
+	}
+
+	defer func() {
+		_, _ = s.db.Exec("DROP TABLE IF EXISTS " + mergeSource)
+		_, _ = s.db.Exec("DROP TABLE IF EXISTS " + mergeInsert)
+	}()
+	return s.inTx(func(tx execer) error {
+		if _, err := tx.Exec("CREATE OR REPLACE TEMP TABLE " + mergeSource + " AS " + stage); err != nil {
+			return err
+		}
+		if err := checkMergeCardinality(tx, c, target, ident(targetAlias), source, on); err != nil {
+			return err
+		}
+
+		var insert *preprocess.MergeClause
+		var matched []preprocess.MergeClause
+		for i, cl := range clauses {
+			if cl.Matched {
+				matched = append(matched, cl)
+			} else {
+				insert = &clauses[i]
+			}
+		}
+		if insert != nil {
+			query := fmt.Sprintf("SELECT %s FROM %s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE %s)",
+				strings.Join(insert.Values, ", "), source, target, on)
+			if insert.Condition != "" {
+				query += " AND (" + insert.Condition + ")"
+			}
+			if _, err := tx.Exec("CREATE OR REPLACE TEMP TABLE " + mergeInsert + " AS " + query); err != nil {
+				return err
+			}
+		}
+
+		// Deletes run first so that no clause sees updated values.
+		for _, action := range []string{"DELETE", "UPDATE"} {
+			for i, cl := range matched {
+				if cl.Action != action {
+					continue
+				}
+				where := on + matchedCondition(matched[:i], cl)
+				var stmt string
+				if action == "DELETE" {
+					stmt = fmt.Sprintf("DELETE FROM %s USING %s WHERE %s", target, source, where)
+				} else {
+					sets := make([]string, len(cl.Set))
+					for j, a := range cl.Set {
+						sets[j] = ident(a.Column) + " = " + a.Expr
+					}
+					stmt = fmt.Sprintf("UPDATE %s SET %s FROM %s WHERE %s", target, strings.Join(sets, ", "), source, where)
+				}
+				if _, err := tx.Exec(stmt); err != nil {
+					return err
+				}
+			}
+		}
+
+		if insert != nil {
+			cols := ""
+			if len(insert.Columns) > 0 {
+				names := make([]string, len(insert.Columns))
+				for i, name := range insert.Columns {
+					names[i] = ident(name)
+				}
+				cols = " (" + strings.Join(names, ", ") + ")"
+			}
+			if _, err := tx.Exec("INSERT INTO " + d.ref.sql() + cols + " SELECT * FROM " + mergeInsert); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// qualifyMergeClause returns a WHEN clause with the references to temporary
+// tables in its expressions qualified.
+func (s *session) qualifyMergeClause(cl preprocess.MergeClause) preprocess.MergeClause {
+	cl.Condition = s.qualifyTemporary(cl.Condition)
+	cl.Set = append([]preprocess.MergeAssignment(nil), cl.Set...)
+	for i := range cl.Set {
+		cl.Set[i].Expr = s.qualifyTemporary(cl.Set[i].Expr)
+	}
+	cl.Values = append([]string(nil), cl.Values...)
+	for i := range cl.Values {
+		cl.Values[i] = s.qualifyTemporary(cl.Values[i])
+	}
+	return cl
+}
+
+// matchedCondition returns the condition a WHEN MATCHED clause adds to the
+// ON condition: its own, and that none of the earlier clauses applied.
+func matchedCondition(earlier []preprocess.MergeClause, cl preprocess.MergeClause) string {
+	var cond string
+	if cl.Condition != "" {
+		cond += " AND (" + cl.Condition + ")"
+	}
+	for _, e := range earlier {
+		cond += " AND NOT coalesce((" + e.Condition + "), false)"
+	}
+	return cond
+}
+
+// checkMergeCardinality fails, as Hive does, if a target row matches more
+// than one source row, which would make the result of WHEN MATCHED clauses
+// depend on the order of the source rows.
+func checkMergeCardinality(tx execer, c *preprocess.Merge, target, alias, source, on string) error {
+	hasMatched := false
+	for _, cl := range c.Clauses {
+		hasMatched = hasMatched || cl.Matched
+	}
+	if !hasMatched {
+		return nil
+	}
+	var n int
+	err := tx.QueryRow(fmt.Sprintf("SELECT count(*) FROM (SELECT %s.rowid FROM %s JOIN %s ON %s GROUP BY %s.rowid HAVING count(*) > 1)",
+		alias, target, source, on, alias)).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("cardinality violation in MERGE INTO %s: %d target row(s) matched more than one source row", c.Target, n)
+	}
+	return nil
+}
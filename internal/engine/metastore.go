@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+)
+
+// The Hive metadata of tables is kept across runs in a tables table of the
+// metadata schema, next to the tables themselves: one row per table holding
+// its catalog entry as JSON. The catalog is loaded from every attached
+// database when a run starts and written back after each emulated command.
+
+// storedTable is a catalog entry as last written to its database.
+type storedTable struct {
+	ref        tableRef
+	definition string
+}
+
+// loadCatalog reads the stored catalog entries of every attached database.
+func (s *session) loadCatalog() error {
+	dbs, err := s.queryNames(`SELECT database_name FROM duckdb_databases() WHERE NOT internal`)
+	if err != nil {
+		return err
+	}
+	for _, db := range dbs {
+		if ok, err := s.metaTableExists(db, "tables"); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		if err := s.loadTables(db); err != nil {
+			return fmt.Errorf("load catalog of %s: %w", db, err)
+		}
+	}
+	return nil
+}
+
+func (s *session) loadTables(db string) error {
+	rows, err := s.db.Query(fmt.Sprintf(`SELECT schema_name, table_name, definition FROM %s.%s.tables`,
+		ident(db), metaSchema))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ref tableRef
+		var definition string
+		if err := rows.Scan(&ref.schema, &ref.Name, &definition); err != nil {
+			return err
+		}
+		ref.catalog = db
+		ref.Database = s.hiveDatabase(db, ref.schema)
+
+		t := &catalog.Table{}
+		if err := json.Unmarshal([]byte(definition), t); err != nil {
+			return fmt.Errorf("table %s: %w", ref.Name, err)
+		}
+		t.Database, t.Name = ref.Database, ref.Name
+		s.catalog.Put(t)
+		s.stored[catalogKey(t)] = storedTable{ref: ref, definition: definition}
+	}
+	return rows.Err()
+}
+
+// syncCatalog writes the catalog entries that changed since they were last
+// written, and deletes the stored entries of dropped tables.
+func (s *session) syncCatalog() error {
+	seen := make(map[string]bool)
+	for _, t := range s.catalog.All() {
+		k := catalogKey(t)
+		seen[k] = true
+		b, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		if st, ok := s.stored[k]; ok && st.definition == string(b) {
+			continue
+		}
+		ref, err := s.resolvePermanent(t.Database + "." + t.Name)
+		if err != nil {
+			return err
+		}
+		if err := s.storeTable(ref, string(b)); err != nil {
+			return fmt.Errorf("store catalog entry of %s: %w", ref, err)
+		}
+		s.stored[k] = storedTable{ref: ref, definition: string(b)}
+	}
+
+	for k, st := range s.stored {
+		if seen[k] {
+			continue
+		}
+		delete(s.stored, k)
+		// The entries of a dropped database went with it.
+		if ok, err := s.metaTableExists(st.ref.catalog, "tables"); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(`DELETE FROM %s.%s.tables WHERE schema_name = ? AND table_name = ?`,
+			ident(st.ref.catalog), metaSchema), st.ref.schema, st.ref.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *session) storeTable(ref tableRef, definition string) error {
+	meta := ident(ref.catalog) + "." + metaSchema
+	return s.inTx(func(tx execer) error {
+		for _, stmt := range []string{
+			"CREATE SCHEMA IF NOT EXISTS " + meta,
+			"CREATE TABLE IF NOT EXISTS " + meta + `.tables (schema_name VARCHAR, table_name VARCHAR,
+				definition VARCHAR, PRIMARY KEY (schema_name, table_name))`,
+		} {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec("INSERT OR REPLACE INTO "+meta+".tables VALUES (?, ?, ?)", ref.schema, ref.Name, definition)
+		return err
+	})
+}
+
+func catalogKey(t *catalog.Table) string {
+	return strings.ToLower(t.Database + "." + t.Name)
+}
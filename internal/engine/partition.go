@@ -0,0 +1,493 @@
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Partitions are tracked in a local registry, the table's catalog entry.
+// File-backed tables are DuckDB views over the files of their registered
+// partitions, so adding or dropping a partition rebuilds the view. Native
+// tables store partition columns as ordinary columns; their partitions are
+// the distinct values in the data plus any registered (possibly empty) ones.
+
+// partitionedTable resolves a table and returns its catalog entry, failing
+// if the table is not partitioned.
+func (s *session) partitionedTable(name string) (tableRef, *catalog.Table, error) {
+	ref, err := s.resolve(name)
+	if err != nil {
+		return ref, nil, err
+	}
+	t, ok := s.catalogFor(ref).Table(ref.Database, ref.Name)
+	if !ok || len(t.PartitionKeys) == 0 {
+		return ref, nil, fmt.Errorf("table %s is not a partitioned table", ref)
+	}
+	return ref, t, nil
+}
+
+// specValues orders the values of a full static spec by partition key.
+func specValues(t *catalog.Table, spec preprocess.PartitionSpec) ([]string, error) {
+	if err := checkPartitionSpec(t, spec); err != nil {
+		return nil, err
+	}
+	values := make([]string, len(t.PartitionKeys))
+	for i, k := range t.PartitionKeys {
+		for _, p := range spec {
+			if strings.EqualFold(p.Key, k.Name) {
+				values[i] = p.Value
+			}
+		}
+	}
+	return values, nil
+}
+
+// partitionLocation returns the directory of a partition: below the table's
+// location, named ds=x/hr=y.
+func partitionLocation(t *catalog.Table, values []string) string {
+	return strings.TrimSuffix(t.Location, "/") + "/" + t.PartitionName(values)
+}
+
+func (s *session) addPartitions(c *preprocess.AddPartitions) error {
+	ref, t, err := s.partitionedTable(c.Table)
+	if err != nil {
+		return err
+	}
+	existing, err := s.partitions(ref, t)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range c.Partitions {
+		values, err := specValues(t, p.Spec)
+		if err != nil {
+			return err
+		}
+		if containsValues(existing, values) {
+			if c.IfNotExists {
+				continue
+			}
+			return fmt.Errorf("partition already exists: %s %s", ref, t.PartitionName(values))
+		}
+		loc := p.Location
+		if loc == "" && t.Location != "" {
+			loc = partitionLocation(t, values)
+		}
+		t.AddPartition(catalog.Partition{Values: values, Location: loc})
+	}
+
+	if t.FileBacked() {
+		return s.refreshFileTable(ref, t)
+	}
+	return nil
+}
+
+func (s *session) dropPartitions(c *preprocess.DropPartitions) error {
+	ref, t, err := s.partitionedTable(c.Table)
+	if err != nil {
+		return err
+	}
+
+	dropped := 0
+	for _, spec := range c.Specs {
+		if err := checkSpecKeys(t, spec); err != nil {
+			return err
+		}
+		kept := t.Partitions[:0]
+		for _, p := range t.Partitions {
+			if matchSpec(t, p.Values, spec) {
+				// Managed tables lose the partition's data too.
+				if t.WarehouseFiles {
+					if err := s.changesFiles("DROP PARTITION of " + ref.String()); err != nil {
+						return err
+					}
+					if err := os.RemoveAll(s.warehousePath(p.Location)); err != nil {
+						return err
+					}
+				}
+				dropped++
+				continue
+			}
+			kept = append(kept, p)
+		}
+		t.Partitions = kept
+
+		if !t.FileBacked() {
+			res, err := s.db.Exec("DELETE FROM " + ref.sql() + " WHERE " + specPredicate(spec))
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			dropped += int(n)
+		}
+	}
+
+	if dropped == 0 && !c.IfExists {
+		return fmt.Errorf("no partition matching %s in %s", c.Specs[0], ref)
+	}
+	if t.FileBacked() {
+		return s.refreshFileTable(ref, t)
+	}
+	return nil
+}
+
+// repairTable emulates MSCK REPAIR TABLE by scanning the table location for
+// key=value partition directories and syncing the registry with them.
+func (s *session) repairTable(c *preprocess.RepairTable) error {
+	ref, err := s.resolve(c.Table)
+	if err != nil {
+		return err
+	}
+	t, ok := s.catalogFor(ref).Table(ref.Database, ref.Name)
+	if !ok || !t.FileBacked() || len(t.PartitionKeys) == 0 {
+		// Nothing to repair: native tables keep partitions in their data.
+		return nil
+	}
+
+	found, err := scanPartitionDirs(s.warehousePath(t.Location), t.PartitionKeys)
+	if err != nil {
+		return err
+	}
+
+	if c.Mode == "ADD" || c.Mode == "SYNC" {
+		for _, values := range found {
+			if t.FindPartition(values) >= 0 {
+				continue
+			}
+			t.AddPartition(catalog.Partition{Values: values, Location: partitionLocation(t, values)})
+			fmt.Fprintf(os.Stderr, "Repair: Added partition to metastore %s:%s\n", ref.Name, t.PartitionName(values))
+		}
+	}
+	if c.Mode == "DROP" || c.Mode == "SYNC" {
+		kept := t.Partitions[:0]
+		for _, p := range t.Partitions {
+			if _, err := os.Stat(s.warehousePath(p.Location)); os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Repair: Dropped partition from metastore %s:%s\n", ref.Name, t.PartitionName(p.Values))
+				continue
+			}
+			kept = append(kept, p)
+		}
+		t.Partitions = kept
+	}
+	return s.refreshFileTable(ref, t)
+}
+
+func (s *session) showPartitions(c *preprocess.ShowPartitions) error {
+	ref, t, err := s.partitionedTable(c.Table)
+	if err != nil {
+		return err
+	}
+	if err := checkSpecKeys(t, c.Partition); err != nil {
+		return err
+	}
+	all, err := s.partitions(ref, t)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]any
+	for _, values := range all {
+		if matchSpec(t, values, c.Partition) {
+			rows = append(rows, []any{t.PartitionName(values)})
+		}
+	}
+	return s.printValues([]string{"partition"}, rows)
+}
+
+func (s *session) truncateTable(c *preprocess.TruncateTable) error {
+	ref, err := s.resolve(c.Table)
+	if err != nil {
+		return err
+	}
+	t, ok := s.catalogFor(ref).Table(ref.Database, ref.Name)
+	if ok && t.WarehouseFiles {
+		return s.truncateFiles(ref, t, c.Partition)
+	}
+	if ok && t.FileBacked() {
+		return fmt.Errorf("cannot truncate non-managed table %s", ref)
+	}
+
+	stmt := "DELETE FROM " + ref.sql()
+	var emptied [][]string
+	if len(c.Partition) > 0 {
+		if !ok || len(t.PartitionKeys) == 0 {
+			return fmt.Errorf("table %s is not a partitioned table", ref)
+		}
+		if err := checkSpecKeys(t, c.Partition); err != nil {
+			return err
+		}
+		stmt += " WHERE " + specPredicate(c.Partition)
+
+		// The partitions of a native table come from its data, so those
+		// emptied are registered to stay, as in Hive
+		all, err := s.partitions(ref, t)
+		if err != nil {
+			return err
+		}
+		for _, values := range all {
+			if matchSpec(t, values, c.Partition) && t.FindPartition(values) < 0 {
+				emptied = append(emptied, values)
+			}
+		}
+	}
+	if _, err := s.db.Exec(stmt); err != nil {
+		return err
+	}
+	for _, values := range emptied {
+		t.AddPartition(catalog.Partition{Values: values})
+	}
+	return nil
+}
+
+// truncateFiles deletes the data files of a managed table kept in the
+// warehouse, or of its partitions matching spec. The partitions stay
+// registered, as in Hive.
+func (s *session) truncateFiles(ref tableRef, t *catalog.Table, spec preprocess.PartitionSpec) error {
+	if err := s.changesFiles("TRUNCATE TABLE " + ref.String()); err != nil {
+		return err
+	}
+	if len(spec) > 0 && len(t.PartitionKeys) == 0 {
+		return fmt.Errorf("table %s is not a partitioned table", ref)
+	}
+	if err := checkSpecKeys(t, spec); err != nil {
+		return err
+	}
+	dirs := []string{t.Location}
+	if len(t.PartitionKeys) > 0 {
+		dirs = dirs[:0]
+		for _, p := range t.Partitions {
+			if matchSpec(t, p.Values, spec) {
+				dirs = append(dirs, p.Location)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		if err := removeDataFiles(s.warehousePath(dir)); err != nil {
+			return err
+		}
+	}
+	return s.refreshFileTable(ref, t)
+}
+
+// partitions returns the values of every partition of a table, sorted by
+// partition name.
+func (s *session) partitions(ref tableRef, t *catalog.Table) ([][]string, error) {
+	var all [][]string
+	for _, p := range t.Partitions {
+		all = append(all, p.Values)
+	}
+
+	if !t.FileBacked() {
+		exprs := make([]string, len(t.PartitionKeys))
+		for i, k := range t.PartitionKeys {
+			exprs[i] = fmt.Sprintf("coalesce(CAST(%s AS VARCHAR), '__HIVE_DEFAULT_PARTITION__')", ident(k.Name))
+		}
+		rows, err := s.db.Query("SELECT DISTINCT " + strings.Join(exprs, ", ") + " FROM " + ref.sql())
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			values := make([]string, len(exprs))
+			ptrs := make([]any, len(exprs))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				return nil, err
+			}
+			if !containsValues(all, values) {
+				all = append(all, values)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(all, func(i, j int) bool { return t.PartitionName(all[i]) < t.PartitionName(all[j]) })
+	return all, nil
+}
+
+func containsValues(all [][]string, values []string) bool {
+	for _, v := range all {
+		if strings.Join(v, "\x00") == strings.Join(values, "\x00") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSpecKeys verifies that every key of a (possibly partial) spec is a
+// partition column.
+func checkSpecKeys(t *catalog.Table, spec preprocess.PartitionSpec) error {
+	for _, p := range spec {
+		if !t.IsPartitionKey(p.Key) {
+			return fmt.Errorf("%s is not a partition column of %s.%s", p.Key, t.Database, t.Name)
+		}
+	}
+	return nil
+}
+
+// matchSpec reports whether partition values satisfy every comparison of a
+// spec. Values compare numerically when both sides are numbers.
+func matchSpec(t *catalog.Table, values []string, spec preprocess.PartitionSpec) bool {
+	for _, p := range spec {
+		for i, k := range t.PartitionKeys {
+			if strings.EqualFold(p.Key, k.Name) && !compareOp(compareValues(values[i], p.Value), p.Op) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compareValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func compareOp(cmp int, op string) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<>", "!=":
+		return cmp != 0
+	}
+	return cmp == 0
+}
+
+// specPredicate renders a spec as a SQL WHERE condition on partition columns.
+func specPredicate(spec preprocess.PartitionSpec) string {
+	conds := make([]string, len(spec))
+	for i, p := range spec {
+		conds[i] = fmt.Sprintf("%s %s %s", ident(p.Key), p.Op, quoteLiteral(p.Value))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// scanPartitionDirs walks root for nested key=value directories matching the
+// partition keys and returns the values of each complete partition path.
+func scanPartitionDirs(root string, keys []catalog.Column) ([][]string, error) {
+	var found [][]string
+	var walk func(dir string, values []string) error
+	walk = func(dir string, values []string) error {
+		if len(values) == len(keys) {
+			found = append(found, append([]string(nil), values...))
+			return nil
+		}
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			k, v, ok := strings.Cut(e.Name(), "=")
+			if !e.IsDir() || !ok || !strings.EqualFold(k, keys[len(values)].Name) {
+				continue
+			}
+			if u, err := url.PathUnescape(v); err == nil {
+				v = u
+			}
+			if err := walk(filepath.Join(dir, e.Name()), append(values, v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, nil); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// refreshFileTable (re)creates the view through which DuckDB reads a
+// file-backed table: a UNION ALL over the files of each partition, with the
+// partition values as constant columns.
+func (s *session) refreshFileTable(ref tableRef, t *catalog.Table) error {
+	cols := make([]catalog.Column, len(t.Columns))
+	for i, c := range t.Columns {
+		typ, err := catalog.DuckDBType(c.Type)
+		if err != nil {
+			return err
+		}
+		cols[i] = catalog.Column{Name: c.Name, Type: typ}
+	}
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = ident(c.Name)
+	}
+
+	var selects []string
+	addSelect := func(dir string, extra []string) error {
+		files, err := findDataFiles(dir)
+		if err != nil || len(files) == 0 {
+			return err
+		}
+		reader, err := readerSQL(files, t, cols)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		selects = append(selects, fmt.Sprintf("SELECT %s FROM %s", strings.Join(append(append([]string{}, names...), extra...), ", "), reader))
+		return nil
+	}
+
+	if len(t.PartitionKeys) == 0 {
+		if err := addSelect(s.warehousePath(t.Location), nil); err != nil {
+			return err
+		}
+	}
+	for _, p := range t.Partitions {
+		extra := make([]string, len(t.PartitionKeys))
+		for i, k := range t.PartitionKeys {
+			typ, err := catalog.DuckDBType(k.Type)
+			if err != nil {
+				return err
+			}
+			extra[i] = fmt.Sprintf("CAST(%s AS %s) AS %s", quoteLiteral(p.Values[i]), typ, ident(k.Name))
+		}
+		if err := addSelect(s.warehousePath(p.Location), extra); err != nil {
+			return err
+		}
+	}
+
+	query := strings.Join(selects, " UNION ALL ")
+	if query == "" {
+		// No data yet: an empty relation with the table's columns.
+		empty := make([]string, 0, len(cols)+len(t.PartitionKeys))
+		for _, c := range cols {
+			empty = append(empty, fmt.Sprintf("CAST(NULL AS %s) AS %s", c.Type, ident(c.Name)))
+		}
+		for _, k := range t.PartitionKeys {
+			typ, _ := catalog.DuckDBType(k.Type)
+			empty = append(empty, fmt.Sprintf("CAST(NULL AS %s) AS %s", typ, ident(k.Name)))
+		}
+		query = "SELECT " + strings.Join(empty, ", ") + " WHERE false"
+	}
+	_, err := s.db.Exec(fmt.Sprintf("CREATE OR REPLACE VIEW %s AS %s", ref.sql(), query))
+	return err
+}
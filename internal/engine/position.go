@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// errorContextPattern matches the context DuckDB adds to errors about a
+// place in a query: the line, possibly cut short with ..., and a caret
+// under the place.
+var errorContextPattern = regexp.MustCompile(`(?m)^(LINE (\d+): )(.*)\n( *)\^`)
+
+// located classifies the error of a statement, unless the statement was
+// stopped, and places it in the script: where DuckDB places the error if
+// the statement ran as written in the script, else where the statement
+// starts.
+func (s *session) located(stmt preprocess.Statement, err error) *StatementError {
+	if e := s.stopped(stmt, err); e != nil {
+		return e
+	}
+	e := classify(err)
+	e.Raw = s.rawErrors
+	if stmt.Origin == nil {
+		return e
+	}
+	e.Position = stmt.Origin.Start()
+	if sql := strings.TrimSpace(stmt.SQL); sql == stmt.Origin.SQL && s.qualifyTemporary(sql) == sql {
+		if offset, ok := errorOffset(sql, err); ok {
+			e.Position = stmt.Origin.Position(offset)
+			before := sql[:offset]
+			e.Line = strings.Count(before, "\n") + 1
+			e.Col = utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:])
+		}
+	}
+	return e
+}
+
+// errorOffset returns the offset in a query of the place a DuckDB error
+// points at, if it points at one.
+func errorOffset(query string, err error) (int, bool) {
+	m := errorContextPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0, false
+	}
+	line, _ := strconv.Atoi(m[2])
+	context, caret := m[3], len(m[4])-len(m[1])
+	if strings.HasPrefix(context, "...") {
+		context, caret = context[3:], caret-3
+	}
+	context = strings.TrimSuffix(context, "...")
+
+	lines := strings.Split(query, "\n")
+	if line < 1 || line > len(lines) {
+		return 0, false
+	}
+	start := strings.Index(lines[line-1], context)
+	if start < 0 || caret < 0 || start+caret > len(lines[line-1]) {
+		return 0, false
+	}
+	offset := start + caret
+	for _, l := range lines[:line-1] {
+		offset += len(l) + 1
+	}
+	return offset, true
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/output"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// hiveOutputKeys are the conf keys the hive output format follows.
+var hiveOutputKeys = map[string]bool{
+	"hive.cli.print.header":                  true,
+	"hive.resultset.use.unique.column.names": true,
+}
+
+// printRows prints the result of a query in the session's output format.
+// In the hive format, columns are named as Hive names them, from the
+// query's select list.
+func (s *session) printRows(rows *sql.Rows, query string) error {
+	s.reportOK()
+	var n int
+	var err error
+	if s.format != output.FormatHive {
+		n, err = output.PrintRows(rows, s.format)
+	} else {
+		opts := s.hiveOptions()
+		unique := s.confBool("hive.resultset.use.unique.column.names", true)
+		opts.Names = func(cols []string) []string {
+			return hiveColumnNames(cols, preprocess.SelectColumns(query), unique)
+		}
+		n, err = output.PrintRowsHive(rows, opts)
+	}
+	s.fetched(n)
+	return err
+}
+
+// printValues prints rows computed by hive-duck in the session's output
+// format.
+func (s *session) printValues(cols []string, rows [][]any) error {
+	s.reportOK()
+	var n int
+	var err error
+	if s.format != output.FormatHive {
+		n, err = output.PrintValues(cols, rows, s.format)
+	} else {
+		n, err = output.PrintValuesHive(cols, rows, s.hiveOptions())
+	}
+	s.fetched(n)
+	return err
+}
+
+func (s *session) hiveOptions() output.HiveOptions {
+	return output.HiveOptions{Header: s.confBool("hive.cli.print.header", false)}
+}
+
+// confBool returns a boolean conf value, or def if it is not set.
+func (s *session) confBool(key string, def bool) bool {
+	v, ok := s.conf.HiveConf[key]
+	if !ok {
+		return def
+	}
+	return strings.EqualFold(strings.TrimSpace(v), "true")
+}
+
+// hiveColumnNames names the columns of a result as Hive does: expressions
+// without an alias are _c0, _c1, ... by their position in the select list,
+// and with unique names, columns read from a table are prefixed with its
+// alias, as in t.id. The result's own names are kept if the select list
+// does not account for them, as with more than one star.
+func hiveColumnNames(cols []string, items []preprocess.SelectColumn, unique bool) []string {
+	stars := 0
+	for _, item := range items {
+		if item.Star {
+			stars++
+		}
+	}
+	if items == nil || stars > 1 || len(items)-stars > len(cols) || (stars == 0 && len(items) != len(cols)) {
+		return cols
+	}
+	starWidth := len(cols) - (len(items) - stars)
+
+	names := make([]string, 0, len(cols))
+	for pos, item := range items {
+		n := 1
+		if item.Star {
+			n = starWidth
+		}
+		for k := 0; k < n; k++ {
+			name := item.Name
+			if item.Star {
+				name = cols[len(names)]
+			} else if name == "" {
+				name = fmt.Sprintf("_c%d", pos)
+			}
+			if unique && item.Table != "" {
+				name = item.Table + "." + name
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
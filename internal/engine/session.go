@@ -0,0 +1,361 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/config"
+	"github.com/danieljhkim/hive-duck/internal/output"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// session holds the state shared by the statements of one run.
+type session struct {
+	db        *conn
+	catalog   *catalog.Catalog
+	dbMap     *config.DatabaseMap
+	warehouse string
+	format    output.Format
+
+	// warehouseTables keeps managed PARQUET tables as files in the
+	// warehouse, as Runner.WarehouseTables.
+	warehouseTables bool
+
+	// warehouseDBs holds the attached databases whose DuckDB files
+	// hive-duck created in the warehouse; DROP DATABASE deletes them.
+	warehouseDBs map[string]bool
+
+	// stored holds the catalog entries as last written to the metadata
+	// schema, keyed by database.table.
+	stored map[string]storedTable
+
+	// temps holds the entries of the session's temporary tables, which are
+	// never stored.
+	temps *catalog.Catalog
+
+	// conf holds the conf values and variables of the session, as changed
+	// by SET and RESET.
+	conf *config.Config
+
+	// settings translates conf keys to DuckDB settings, and applied holds
+	// the DuckDB settings SET changed, which RESET restores.
+	settings map[string]config.Setting
+	applied  map[string]bool
+
+	// compression is the codec of the Parquet files written with COPY, if
+	// the conf sets one.
+	compression string
+
+	// verbose is the verbosity level of Runner.Verbose.
+	verbose int
+
+	// run is the context of the run, which expires after timeout, and
+	// statementTimeout limits each statement, as Runner.Timeout and
+	// Runner.StatementTimeout.
+	run              context.Context
+	timeout          time.Duration
+	statementTimeout time.Duration
+
+	// atomic is set while the transaction of an atomic run is open.
+	atomic bool
+
+	// rawErrors appends DuckDB's own messages to statement errors.
+	rawErrors bool
+
+	// status is the report of the statement being run.
+	status status
+}
+
+func (s *session) close() {
+	if err := s.db.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
+}
+
+// tableRef identifies a table both by its Hive name and by where DuckDB
+// stores it.
+type tableRef struct {
+	Database string // Hive database
+	Name     string
+	catalog  string // DuckDB catalog (attached database)
+	schema   string // DuckDB schema
+}
+
+// String returns the Hive name, database.table.
+func (t tableRef) String() string {
+	return t.Database + "." + t.Name
+}
+
+// sql returns the fully qualified DuckDB name.
+func (t tableRef) sql() string {
+	return ident(t.catalog) + "." + ident(t.schema) + "." + ident(t.Name)
+}
+
+// resolve maps a possibly qualified Hive table name to a tableRef, which is
+// to a temporary table if one by that name exists in the database.
+func (s *session) resolve(name string) (tableRef, error) {
+	ref, err := s.resolvePermanent(name)
+	if err != nil {
+		return ref, err
+	}
+	ref, _ = s.temporaryRef(ref)
+	return ref, nil
+}
+
+// resolvePermanent maps a possibly qualified Hive table name to a tableRef,
+// ignoring temporary tables.
+func (s *session) resolvePermanent(name string) (tableRef, error) {
+	parts := strings.Split(strings.ToLower(name), ".")
+	var db string
+	if len(parts) > 1 {
+		db = parts[len(parts)-2]
+	}
+	ref, err := s.resolveDatabase(db)
+	ref.Name = parts[len(parts)-1]
+	return ref, err
+}
+
+// resolveDatabase maps a Hive database, or the current one if db is empty,
+// to a tableRef without a table name. With a DatabaseMap, Hive databases are
+// attached DuckDB databases; otherwise they are schemas of the main
+// database, with Hive's "default" being "main".
+func (s *session) resolveDatabase(db string) (tableRef, error) {
+	var ref tableRef
+	if err := s.db.QueryRow("SELECT current_database(), current_schema()").Scan(&ref.catalog, &ref.schema); err != nil {
+		return ref, fmt.Errorf("resolve current database: %w", err)
+	}
+
+	if db = strings.ToLower(db); db != "" {
+		if s.dbMap != nil {
+			ref.catalog, ref.schema = db, "main"
+		} else if db == "default" {
+			ref.schema = "main"
+		} else {
+			ref.schema = db
+		}
+	}
+
+	ref.Database = s.hiveDatabase(ref.catalog, ref.schema)
+	return ref, nil
+}
+
+// hiveDatabase returns the Hive database stored in a DuckDB catalog and
+// schema, the inverse of resolveDatabase.
+func (s *session) hiveDatabase(catalogName, schema string) string {
+	if s.dbMap != nil {
+		return catalogName
+	}
+	if schema == "main" {
+		return "default"
+	}
+	return schema
+}
+
+// columns returns the DuckDB columns of a table in declaration order, with
+// DuckDB type names and comments. It returns no columns if the table does
+// not exist.
+func (s *session) columns(ref tableRef) ([]catalog.Column, error) {
+	rows, err := s.db.Query(`SELECT column_name, data_type, coalesce(comment, '') FROM duckdb_columns()
+		WHERE database_name = ? AND schema_name = ? AND lower(table_name) = ?
+		ORDER BY column_index`, ref.catalog, ref.schema, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []catalog.Column
+	for rows.Next() {
+		var c catalog.Column
+		if err := rows.Scan(&c.Name, &c.Type, &c.Comment); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+// execCommand runs a Hive statement that the engine emulates.
+func (s *session) execCommand(cmd preprocess.Command) error {
+	switch c := cmd.(type) {
+	case *preprocess.SetConf:
+		return s.setConf(c)
+	case *preprocess.ShowConf:
+		return s.showConf(c)
+	case *preprocess.ResetConf:
+		return s.resetConf()
+	case *preprocess.UseDatabase:
+		return s.useDatabase(c)
+	case *preprocess.CreateDatabase:
+		return s.createDatabase(c)
+	case *preprocess.DropDatabase:
+		return s.dropDatabase(c)
+	case *preprocess.AlterDatabase:
+		return s.alterDatabase(c)
+	case *preprocess.CreateTable:
+		if c.Temporary {
+			return s.createTemporaryTable(c)
+		}
+		return s.createTable(c)
+	case *preprocess.DropTable:
+		return s.dropTable(c)
+	case *preprocess.LoadData:
+		return s.loadData(c)
+	case *preprocess.CreateMaterializedView:
+		return s.createMaterializedView(c)
+	case *preprocess.AlterMaterializedView:
+		return s.alterMaterializedView(c)
+	case *preprocess.DropMaterializedView:
+		return s.dropMaterializedView(c)
+	case *preprocess.Merge:
+		return s.merge(c)
+	case *preprocess.ExportTable:
+		return s.exportTable(c)
+	case *preprocess.ImportTable:
+		return s.importTable(c)
+	case *preprocess.Insert:
+		return s.insert(c)
+	case *preprocess.AddPartitions:
+		return s.addPartitions(c)
+	case *preprocess.DropPartitions:
+		return s.dropPartitions(c)
+	case *preprocess.RepairTable:
+		return s.repairTable(c)
+	case *preprocess.ShowPartitions:
+		return s.showPartitions(c)
+	case *preprocess.TruncateTable:
+		return s.truncateTable(c)
+	case *preprocess.Describe:
+		return s.describe(c)
+	case *preprocess.ShowTblProperties:
+		return s.showTblProperties(c)
+	case *preprocess.ShowCreateTable:
+		return s.showCreateTable(c)
+	case *preprocess.ChangeColumn:
+		return s.changeColumn(c)
+	case *preprocess.AddColumns:
+		return s.addColumns(c)
+	case *preprocess.RenameTable:
+		return s.renameTable(c)
+	case *preprocess.SetTableProperties:
+		return s.setTableProperties(c)
+	case *preprocess.SetSerDe:
+		return s.setSerDe(c)
+	case *preprocess.SetLocation:
+		return s.setLocation(c)
+	case *preprocess.SetFileFormat:
+		return s.setFileFormat(c)
+	case *preprocess.ShowDatabases:
+		return s.showDatabases(c)
+	case *preprocess.ShowTables:
+		return s.showTables(c)
+	case *preprocess.ShowColumns:
+		return s.showColumns(c)
+	case *preprocess.ShowFunctions:
+		return s.showFunctions(c)
+	case *preprocess.DescribeFunction:
+		return s.describeFunction(c)
+	case *preprocess.DescribeDatabase:
+		return s.describeDatabase(c)
+	default:
+		return fmt.Errorf("unsupported command %T", cmd)
+	}
+}
+
+// createTable records the Hive metadata of a table created by CREATE TABLE.
+// File-backed tables are created here as views over their location; for
+// managed tables kept in the warehouse that is <database dir>/<table>, and
+// the query of a CREATE TABLE ... AS SELECT is inserted into them.
+func (s *session) createTable(c *preprocess.CreateTable) error {
+	ref, err := s.resolvePermanent(c.Name)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.catalog.Table(ref.Database, ref.Name); ok && c.IfNotExists {
+		return nil
+	}
+
+	t := *c.Table
+	t.Database, t.Name = ref.Database, ref.Name
+	t.CreateTime = time.Now()
+	if c.Query != "" && !t.WarehouseFiles {
+		return fmt.Errorf("CREATE TABLE AS SELECT cannot create external table %s", ref)
+	}
+	if t.WarehouseFiles && t.Location == "" {
+		if s.warehouse == "" {
+			return fmt.Errorf("managed table %s is stored in the warehouse, but no warehouse directory is set; set hive.metastore.warehouse.dir", ref)
+		}
+		dir, err := filepath.Abs(s.tableDir(ref, nil))
+		if err != nil {
+			return err
+		}
+		t.Location = "file://" + dir
+	}
+	if c.Query != "" {
+		if t.Columns, err = s.describeQuery("(" + s.qualifyTemporary(c.Query) + ")"); err != nil {
+			return err
+		}
+	}
+	if t.FileBacked() {
+		cols, err := s.columns(ref)
+		if err != nil {
+			return err
+		}
+		if len(cols) > 0 {
+			if c.IfNotExists {
+				return nil
+			}
+			return fmt.Errorf("table already exists: %s", ref)
+		}
+		if err := s.refreshFileTable(ref, &t); err != nil {
+			return err
+		}
+	}
+	s.catalog.Put(&t)
+	if c.Query != "" {
+		return s.insert(&preprocess.Insert{Table: ref.String(), Query: c.Query})
+	}
+	return nil
+}
+
+// dropTable drops a table (or the view of a file-backed table) together
+// with its catalog entry. As in Hive, the files of external tables are kept
+// and those of managed tables kept in the warehouse are deleted.
+func (s *session) dropTable(c *preprocess.DropTable) error {
+	ref, err := s.resolve(c.Table)
+	if err != nil {
+		return err
+	}
+	kind := "TABLE"
+	t, ok := s.catalogFor(ref).Table(ref.Database, ref.Name)
+	if ok && t.Type == catalog.MaterializedView {
+		return fmt.Errorf("cannot drop materialized view %s with DROP TABLE; use DROP MATERIALIZED VIEW", ref)
+	}
+	if ok && t.FileBacked() {
+		kind = "VIEW"
+	}
+	if ok && t.WarehouseFiles {
+		if err := s.changesFiles("DROP TABLE " + ref.String()); err != nil {
+			return err
+		}
+	}
+	stmt := "DROP " + kind + " "
+	if c.IfExists {
+		stmt += "IF EXISTS "
+	}
+	if _, err := s.db.Exec(stmt + ref.sql()); err != nil {
+		return err
+	}
+	if ok && t.WarehouseFiles {
+		if err := os.RemoveAll(s.warehousePath(t.Location)); err != nil {
+			return err
+		}
+	}
+	s.catalogFor(ref).Drop(ref.Database, ref.Name)
+	return nil
+}
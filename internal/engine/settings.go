@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/config"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Conf keys with a local meaning, such as mapreduce.map.memory.mb, are
+// translated into DuckDB settings when they are SET or given with
+// --hiveconf; see config.Settings. Other keys are only kept for SET and
+// variable substitution.
+
+// parquetCodecs maps the compression codecs of Hive's ORC and Parquet conf
+// keys to DuckDB's Parquet codecs.
+var parquetCodecs = map[string]string{
+	"none":         "uncompressed",
+	"uncompressed": "uncompressed",
+	"zlib":         "gzip",
+	"gzip":         "gzip",
+	"snappy":       "snappy",
+	"lz4":          "lz4",
+	"zstd":         "zstd",
+}
+
+func (s *session) setConf(c *preprocess.SetConf) error {
+	if err := s.conf.Set(c.Key, c.Value); err != nil {
+		return err
+	}
+	return s.applySetting(c.Key, c.Value)
+}
+
+// applySetting translates SET key=value into a DuckDB setting if key has a
+// local meaning.
+func (s *session) applySetting(key, value string) error {
+	name, isConf := config.ConfKey(key)
+	if !isConf {
+		return nil
+	}
+	if hiveOutputKeys[name] {
+		s.verbosef("SET %s=%s applied to the hive output format", key, value)
+		return nil
+	}
+	setting, ok := s.settings[name]
+	if !ok {
+		s.verbosef("SET %s=%s ignored: no local equivalent", key, value)
+		return nil
+	}
+
+	if setting.Name == config.CopyCompression {
+		codec, ok := parquetCodecs[strings.ToLower(value)]
+		if !ok {
+			s.verbosef("SET %s=%s ignored: no Parquet codec for %s", key, value, value)
+			return nil
+		}
+		s.compression = codec
+		s.verbosef("SET %s=%s applied as Parquet compression %s", key, value, codec)
+		return nil
+	}
+	stmt := fmt.Sprintf("SET %s = %s", setting.Name, quoteLiteral(value+setting.Unit))
+	if _, err := s.db.Exec(stmt); err != nil {
+		return fmt.Errorf("SET %s=%s: %w", key, value, err)
+	}
+	s.applied[setting.Name] = true
+	s.verbosef("SET %s=%s applied as %s", key, value, stmt)
+	return nil
+}
+
+// resetConf restores the conf values of the command line, and the DuckDB
+// settings with them.
+func (s *session) resetConf() error {
+	s.conf.Reset()
+	s.compression = ""
+	for name := range s.applied {
+		if _, err := s.db.Exec("RESET " + name); err != nil {
+			return err
+		}
+	}
+	s.applied = make(map[string]bool)
+	return s.applyConf()
+}
+
+// applyConf applies the conf values that have a local meaning, in key order.
+func (s *session) applyConf() error {
+	keys := make([]string, 0, len(s.conf.HiveConf))
+	for k := range s.conf.HiveConf {
+		if _, ok := s.settings[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := s.applySetting(k, s.conf.HiveConf[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parquetOptions returns the options of the COPY statements writing Parquet
+// files, with the compression set by the conf.
+func (s *session) parquetOptions() string {
+	if s.compression == "" {
+		return "(FORMAT parquet)"
+	}
+	return "(FORMAT parquet, COMPRESSION " + s.compression + ")"
+}
+
+// verbosef reports what the session does to stderr in verbose mode.
+func (s *session) verbosef(format string, args ...any) {
+	if s.verbose > 0 {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
@@ -0,0 +1,322 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// hiveLike compiles a SHOW ... LIKE pattern. Hive patterns are
+// case-insensitive globs where * matches anything and | separates
+// alternatives, as in 'fact_*|dim_*'. An empty pattern matches everything.
+func hiveLike(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return regexp.MustCompile(`.*`)
+	}
+	alts := strings.Split(pattern, "|")
+	for i, alt := range alts {
+		parts := strings.Split(strings.TrimSpace(alt), "*")
+		for j, p := range parts {
+			parts[j] = regexp.QuoteMeta(p)
+		}
+		alts[i] = strings.Join(parts, ".*")
+	}
+	return regexp.MustCompile(`(?i)^(?:` + strings.Join(alts, "|") + `)$`)
+}
+
+// printNames prints the names matching pattern, sorted, as a single column.
+func (s *session) printNames(column string, names []string, pattern string) error {
+	like := hiveLike(pattern)
+	sort.Strings(names)
+	var rows [][]any
+	for i, name := range names {
+		if like.MatchString(name) && (i == 0 || names[i-1] != name) {
+			rows = append(rows, []any{name})
+		}
+	}
+	return s.printValues([]string{column}, rows)
+}
+
+// queryNames runs a query returning one string column.
+func (s *session) queryNames(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// databases lists the Hive databases: the mapped databases with a
+// DatabaseMap, otherwise the schemas of the main database.
+func (s *session) databases() ([]string, error) {
+	if s.dbMap != nil {
+		return s.dbMap.DatabaseNames(), nil
+	}
+	ref, err := s.resolveDatabase("")
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := s.queryNames(`SELECT schema_name FROM duckdb_schemas()
+		WHERE database_name = ? AND schema_name <> ? AND NOT internal`, ref.catalog, metaSchema)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{"default"}
+	for _, name := range schemas {
+		if name != "main" {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	return names, nil
+}
+
+// databaseExists reports whether a Hive database exists.
+func (s *session) databaseExists(db string) (bool, error) {
+	names, err := s.databases()
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, db) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *session) showDatabases(c *preprocess.ShowDatabases) error {
+	names, err := s.databases()
+	if err != nil {
+		return err
+	}
+	return s.printNames("database_name", names, c.Pattern)
+}
+
+// showTables lists the tables and views of a database, temporary tables
+// included; SHOW VIEWS lists
+// only the views, leaving out the views that stand in for file-backed tables,
+// and SHOW MATERIALIZED VIEWS the materialized views in the catalog.
+func (s *session) showTables(c *preprocess.ShowTables) error {
+	if c.Database != "" {
+		if ok, err := s.databaseExists(c.Database); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("database does not exist: %s", c.Database)
+		}
+	}
+	ref, err := s.resolveDatabase(c.Database)
+	if err != nil {
+		return err
+	}
+	if c.Materialized {
+		var names []string
+		for _, t := range s.catalog.Tables(ref.Database) {
+			if t.Type == catalog.MaterializedView {
+				names = append(names, t.Name)
+			}
+		}
+		return s.printNames("tab_name", names, c.Pattern)
+	}
+
+	views, err := s.queryNames(`SELECT lower(view_name) FROM duckdb_views()
+		WHERE database_name = ? AND schema_name = ? AND NOT internal`, ref.catalog, ref.schema)
+	if err != nil {
+		return err
+	}
+	if c.Views {
+		var names []string
+		for _, name := range views {
+			if t, ok := s.catalog.Table(ref.Database, name); !ok || !t.FileBacked() {
+				names = append(names, name)
+			}
+		}
+		return s.printNames("tab_name", names, c.Pattern)
+	}
+
+	tables, err := s.queryNames(`SELECT lower(table_name) FROM duckdb_tables()
+		WHERE database_name = ? AND schema_name = ?`, ref.catalog, ref.schema)
+	if err != nil {
+		return err
+	}
+	for _, t := range s.temps.Tables(ref.Database) {
+		tables = append(tables, t.Name)
+	}
+	return s.printNames("tab_name", append(tables, views...), c.Pattern)
+}
+
+// showColumns lists a table's columns, partition columns included, in
+// declaration order.
+func (s *session) showColumns(c *preprocess.ShowColumns) error {
+	d, err := s.lookupTable(c.Table)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return fmt.Errorf("table not found: %s", c.Table)
+	}
+	like := hiveLike(c.Pattern)
+	var rows [][]any
+	cols := append(append([]catalog.Column{}, d.cols...), d.table.PartitionKeys...)
+	for _, col := range cols {
+		if like.MatchString(col.Name) {
+			rows = append(rows, []any{col.Name})
+		}
+	}
+	return s.printValues([]string{"field"}, rows)
+}
+
+func (s *session) showFunctions(c *preprocess.ShowFunctions) error {
+	names, err := s.queryNames(`SELECT DISTINCT function_name FROM duckdb_functions()`)
+	if err != nil {
+		return err
+	}
+	return s.printNames("tab_name", names, c.Pattern)
+}
+
+// describeFunction prints a function's signature and description from
+// duckdb_functions(); EXTENDED adds every overload and an example.
+func (s *session) describeFunction(c *preprocess.DescribeFunction) error {
+	rows, err := s.db.Query(`SELECT coalesce(description, ''), coalesce(example, ''),
+			array_to_string(parameters, ', '), array_to_string(parameter_types, ', '),
+			coalesce(return_type, ''), internal
+		FROM duckdb_functions() WHERE lower(function_name) = lower(?)`, c.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type overload struct {
+		description, example, params, types, returns string
+		internal                                     bool
+	}
+	var overloads []overload
+	for rows.Next() {
+		var o overload
+		var params, types sql.NullString
+		if err := rows.Scan(&o.description, &o.example, &params, &types, &o.returns, &o.internal); err != nil {
+			return err
+		}
+		o.params, o.types = params.String, types.String
+		overloads = append(overloads, o)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var lines []string
+	if len(overloads) == 0 {
+		lines = append(lines, fmt.Sprintf("Function '%s' does not exist.", c.Name))
+	} else {
+		first := overloads[0]
+		for _, o := range overloads {
+			if first.description == "" && o.description != "" {
+				first = o
+			}
+		}
+		if first.description == "" {
+			lines = append(lines, fmt.Sprintf("There is no documentation for function '%s'", c.Name))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s(%s) - %s", c.Name, first.params, first.description))
+		}
+		if c.Extended {
+			seen := make(map[string]bool)
+			for _, o := range overloads {
+				sig := fmt.Sprintf("Signature: %s(%s)", c.Name, o.types)
+				if o.returns != "" {
+					sig += " -> " + o.returns
+				}
+				if !seen[sig] {
+					seen[sig] = true
+					lines = append(lines, sig)
+				}
+			}
+			if first.example != "" {
+				lines = append(lines, "Example:", "  > SELECT "+first.example+";")
+			}
+			kind := "BUILTIN"
+			if !first.internal {
+				kind = "TEMPORARY"
+			}
+			lines = append(lines, "Function type:"+kind)
+		}
+	}
+
+	out := make([][]any, len(lines))
+	for i, line := range lines {
+		out[i] = []any{line}
+	}
+	return s.printValues([]string{"tab_name"}, out)
+}
+
+// describeDatabase prints Hive's database description. The location is the
+// database's LOCATION or warehouse directory if either is known, else the
+// DuckDB database file.
+func (s *session) describeDatabase(c *preprocess.DescribeDatabase) error {
+	if ok, err := s.databaseExists(c.Name); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("database does not exist: %s", c.Name)
+	}
+	ref, err := s.resolveDatabase(c.Name)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.databaseInfo(ref)
+	if err != nil {
+		return err
+	}
+	location := info.Location
+	if location == "" && s.warehouse != "" {
+		location = fileURI(s.databaseDir(ref))
+	} else if location == "" {
+		var path sql.NullString
+		if err := s.db.QueryRow(`SELECT path FROM duckdb_databases() WHERE database_name = ?`, ref.catalog).Scan(&path); err != nil {
+			return err
+		}
+		if path.String != "" {
+			location = fileURI(path.String)
+		}
+	}
+
+	// Parameters are only shown by DESCRIBE DATABASE EXTENDED.
+	var params string
+	if c.Extended {
+		params = hiveParameters(info.Properties)
+	}
+	row := []any{ref.Database, info.Comment, location, s.owner(), "USER", params}
+	cols := []string{"db_name", "comment", "location", "owner_name", "owner_type", "parameters"}
+	return s.printValues(cols, [][]any{row})
+}
+
+// showConf prints the value of one key as SET key does, key=value or key
+// is undefined, or every value.
+func (s *session) showConf(c *preprocess.ShowConf) error {
+	if c.Key == "" {
+		lines := s.conf.Entries(c.Verbose)
+		rows := make([][]any, len(lines))
+		for i, line := range lines {
+			rows[i] = []any{line}
+		}
+		return s.printValues([]string{"set"}, rows)
+	}
+	line := c.Key + " is undefined"
+	if v, ok := s.conf.Get(c.Key); ok {
+		line = c.Key + "=" + v
+	}
+	return s.printValues([]string{"set"}, [][]any{{line}})
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// showCreateTable prints Hive DDL for a table, one line per row as Hive
+// does: the columns with Hive types, then the preserved partition, bucket,
+// storage, location and property clauses.
+func (s *session) showCreateTable(c *preprocess.ShowCreateTable) error {
+	d, err := s.lookupTable(c.Table)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return fmt.Errorf("table not found: %s", c.Table)
+	}
+
+	var ddl string
+	if d.table.Type == catalog.VirtualView {
+		ddl = fmt.Sprintf("CREATE VIEW `%s` AS %s", d.ref.Name, d.view)
+	} else {
+		ddl = hiveCreateTable(d, s.location(d))
+	}
+	var rows [][]any
+	for _, line := range strings.Split(ddl, "\n") {
+		rows = append(rows, []any{line})
+	}
+	return s.printValues([]string{"createtab_stmt"}, rows)
+}
+
+// hiveCreateTable renders a table's CREATE TABLE statement in the layout
+// Hive's SHOW CREATE TABLE uses.
+func hiveCreateTable(d *described, location string) string {
+	t := d.table
+	var b strings.Builder
+	kind := "TABLE"
+	if t.Type == catalog.ExternalTable {
+		kind = "EXTERNAL TABLE"
+	}
+	fmt.Fprintf(&b, "CREATE %s `%s`(\n%s)\n", kind, d.ref.Name, columnDefs(d.cols))
+	if t.Comment != "" {
+		fmt.Fprintf(&b, "COMMENT %s\n", hiveString(t.Comment))
+	}
+	if len(t.PartitionKeys) > 0 {
+		fmt.Fprintf(&b, "PARTITIONED BY (\n%s)\n", columnDefs(t.PartitionKeys))
+	}
+	if t.Buckets != nil {
+		fmt.Fprintf(&b, "CLUSTERED BY (\n  %s)\n", strings.Join(t.Buckets.Columns, ",\n  "))
+		if len(t.Buckets.SortBy) > 0 {
+			sortBy := make([]string, len(t.Buckets.SortBy))
+			for i, col := range t.Buckets.SortBy {
+				if fields := strings.Fields(col); len(fields) == 1 {
+					col += " ASC"
+				}
+				sortBy[i] = col
+			}
+			fmt.Fprintf(&b, "SORTED BY (\n  %s)\n", strings.Join(sortBy, ",\n  "))
+		}
+		fmt.Fprintf(&b, "INTO %d BUCKETS\n", t.Buckets.Count)
+	}
+
+	serde, input, output := t.Storage.Classes()
+	fmt.Fprintf(&b, "ROW FORMAT SERDE\n  %s\n", hiveString(serde))
+	params := t.Storage.Params()
+	// The default serialization.format is left out, as Hive does.
+	if params["serialization.format"] == "1" {
+		delete(params, "serialization.format")
+	}
+	if len(params) > 0 {
+		fmt.Fprintf(&b, "WITH SERDEPROPERTIES (\n%s)\n", propertyDefs(params))
+	}
+	fmt.Fprintf(&b, "STORED AS INPUTFORMAT\n  %s\nOUTPUTFORMAT\n  %s", hiveString(input), hiveString(output))
+
+	if location != "" {
+		fmt.Fprintf(&b, "\nLOCATION\n  %s", hiveString(location))
+	}
+	if len(t.Properties) > 0 {
+		fmt.Fprintf(&b, "\nTBLPROPERTIES (\n%s)", propertyDefs(t.Properties))
+	}
+	return b.String()
+}
+
+func columnDefs(cols []catalog.Column) string {
+	defs := make([]string, len(cols))
+	for i, c := range cols {
+		defs[i] = fmt.Sprintf("  `%s` %s", c.Name, c.Type)
+		if c.Comment != "" {
+			defs[i] += " COMMENT " + hiveString(c.Comment)
+		}
+	}
+	return strings.Join(defs, ",\n")
+}
+
+func propertyDefs(props map[string]string) string {
+	defs := make([]string, 0, len(props))
+	for _, k := range sortedKeys(props) {
+		defs = append(defs, fmt.Sprintf("  %s=%s", hiveString(k), hiveString(props[k])))
+	}
+	return strings.Join(defs, ",\n")
+}
+
+// hiveString quotes s as a string literal that hive-duck reads back: quotes
+// are doubled, and backslashes and control characters such as the default
+// \u0001 field delimiter are escaped.
+func hiveString(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			b.WriteString("''")
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case !unicode.IsPrint(r):
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Like the Hive CLI, the session reports each statement on stderr: OK once
+// it ran, before any rows it prints, then the time it took and the number
+// of rows fetched, as in
+//
+//	OK
+//	Time taken: 0.052 seconds, Fetched: 3 row(s)
+//
+// -S turns the reports off. DML statements add the number of rows they
+// wrote before OK.
+
+// status is the report of the statement being run.
+type status struct {
+	report  bool   // whether the statement is reported
+	ok      bool   // OK was printed
+	fetched int    // rows printed, or -1 if the statement printed none
+	summary string // what the statement wrote, such as Loaded 3 row(s)
+}
+
+// reportsStatus reports whether Hive prints a status for a statement; it
+// prints none for SET and RESET.
+func reportsStatus(stmt preprocess.Statement) bool {
+	switch stmt.Command.(type) {
+	case *preprocess.SetConf, *preprocess.ShowConf, *preprocess.ResetConf:
+		return false
+	}
+	return true
+}
+
+// beginStatus starts the report of a statement.
+func (s *session) beginStatus(report bool) {
+	s.status = status{report: report, fetched: -1}
+}
+
+// reportOK prints OK once the statement ran, before its rows.
+func (s *session) reportOK() {
+	if !s.status.report || s.status.ok {
+		return
+	}
+	if s.status.summary != "" {
+		fmt.Fprintln(os.Stderr, s.status.summary)
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+	s.status.ok = true
+}
+
+// fetched records rows printed by the statement.
+func (s *session) fetched(n int) {
+	if s.status.fetched < 0 {
+		s.status.fetched = 0
+	}
+	s.status.fetched += n
+}
+
+// wrote records the rows a DML statement wrote.
+func (s *session) wrote(verb string, n int64) {
+	s.status.summary = fmt.Sprintf("%s %d row(s)", verb, n)
+}
+
+// endStatus prints the end of the report of a statement that succeeded.
+func (s *session) endStatus(elapsed time.Duration) {
+	if !s.status.report {
+		return
+	}
+	s.reportOK()
+	line := "Time taken: " + seconds(elapsed) + " seconds"
+	if s.status.fetched >= 0 {
+		line += fmt.Sprintf(", Fetched: %d row(s)", s.status.fetched)
+	}
+	fmt.Fprintln(os.Stderr, line)
+}
+
+// seconds formats a duration in seconds with millisecond precision, as
+// Hive does: 0.05, 1.0, 12.345.
+func seconds(d time.Duration) string {
+	s := strconv.FormatFloat(float64(d.Milliseconds())/1000, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// dmlVerb returns the verb reporting the rows a DML statement writes, or ""
+// for other statements.
+func dmlVerb(stmt string) string {
+	word, _, _ := strings.Cut(strings.TrimSpace(stmt), " ")
+	switch strings.ToUpper(word) {
+	case "INSERT":
+		return "Loaded"
+	case "UPDATE":
+		return "Updated"
+	case "DELETE":
+		return "Deleted"
+	}
+	return ""
+}
+
+// echoOrigin echoes the Hive statement a statement was rewritten from in
+// verbose mode, as hive -v does.
+func (s *session) echoOrigin(stmt preprocess.Statement) {
+	if stmt.Origin != nil {
+		s.verbosef("%s", stmt.Origin.SQL)
+	}
+}
+
+// echoRewritten echoes a rewritten statement from verbosity level 2 on: its
+// SQL and the command the engine runs after it.
+func (s *session) echoRewritten(stmt preprocess.Statement) {
+	if s.verbose < 2 {
+		return
+	}
+	if stmt.SQL != "" {
+		fmt.Fprintln(os.Stderr, "  -> "+strings.ReplaceAll(stmt.SQL, "\n", "\n     "))
+	}
+	if stmt.Command != nil {
+		fmt.Fprintln(os.Stderr, "  -> -- hive-duck: "+stmt.Command.String())
+	}
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+	"github.com/danieljhkim/hive-duck/internal/preprocess"
+)
+
+// Temporary tables live in an in-memory database attached for the session,
+// in a schema named after their Hive database, so that they can share the
+// name of a permanent table and go away when the session ends, however it
+// ends. As in Hive, a temporary table takes precedence over a permanent one
+// of the same name in its database: the engine resolves names to it, and
+// references in SQL are rewritten to its qualified name.
+
+// tempCatalog is the attached database holding the temporary tables.
+const tempCatalog = "__hive_duck_temp"
+
+// isTemporary reports whether a table reference is to a temporary table.
+func (t tableRef) isTemporary() bool {
+	return t.catalog == tempCatalog
+}
+
+// catalogFor returns the catalog holding the entry of a table: the
+// session's temporary tables or the persistent catalog.
+func (s *session) catalogFor(ref tableRef) *catalog.Catalog {
+	if ref.isTemporary() {
+		return s.temps
+	}
+	return s.catalog
+}
+
+// temporaryRef returns the reference to the temporary table that a
+// permanent reference resolves to, if there is one.
+func (s *session) temporaryRef(ref tableRef) (tableRef, bool) {
+	if _, ok := s.temps.Table(ref.Database, ref.Name); !ok {
+		return ref, false
+	}
+	ref.catalog, ref.schema = tempCatalog, ref.Database
+	return ref, true
+}
+
+func (s *session) createTemporaryTable(c *preprocess.CreateTable) error {
+	ref, err := s.resolvePermanent(c.Name)
+	if err != nil {
+		return err
+	}
+	if ok, err := s.databaseExists(ref.Database); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("database does not exist: %s", ref.Database)
+	}
+	if _, ok := s.temps.Table(ref.Database, ref.Name); ok {
+		if c.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("table already exists: %s", ref)
+	}
+	t := *c.Table
+	switch {
+	case len(t.PartitionKeys) > 0:
+		return fmt.Errorf("partition columns are not supported on temporary table %s", ref)
+	case t.Type == catalog.ExternalTable:
+		return fmt.Errorf("temporary table %s cannot be external", ref)
+	}
+
+	if ref, err = s.temporarySchema(ref); err != nil {
+		return err
+	}
+	if c.Query != "" {
+		if _, err := s.db.Exec("CREATE TABLE " + ref.sql() + " AS " + s.qualifyTemporary(c.Query)); err != nil {
+			return err
+		}
+		if t.Columns, err = s.hiveColumns(ref, &catalog.Table{}); err != nil {
+			return err
+		}
+	} else if err := s.createDuckDBTable(ref, &t); err != nil {
+		return err
+	}
+
+	t.Database, t.Name = ref.Database, ref.Name
+	t.CreateTime = time.Now()
+	s.temps.Put(&t)
+	return nil
+}
+
+// temporarySchema returns the reference to a table of a Hive database in the
+// temporary tables' database, creating the schema of the Hive database if
+// needed.
+func (s *session) temporarySchema(ref tableRef) (tableRef, error) {
+	for _, stmt := range []string{
+		"ATTACH IF NOT EXISTS ':memory:' AS " + ident(tempCatalog),
+		"CREATE SCHEMA IF NOT EXISTS " + ident(tempCatalog) + "." + ident(ref.Database),
+	} {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return ref, err
+		}
+	}
+	ref.catalog, ref.schema = tempCatalog, ref.Database
+	return ref, nil
+}
+
+// qualifyTemporary rewrites the references to temporary tables in a SQL
+// statement to their qualified names: db.name names if db has such a
+// temporary table, and unqualified names if the current database has.
+func (s *session) qualifyTemporary(stmt string) string {
+	if len(s.temps.All()) == 0 {
+		return stmt
+	}
+	current, err := s.resolveDatabase("")
+	if err != nil {
+		return stmt
+	}
+	return preprocess.ReplaceTableNames(stmt, func(db, name string) (string, bool) {
+		if db == "" {
+			db = current.Database
+		}
+		ref, ok := s.temporaryRef(tableRef{Database: db, Name: name})
+		return ref.sql(), ok
+	})
+}
@@ -0,0 +1,240 @@
+package output
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+// HiveOptions are the Hive CLI settings the hive format follows.
+type HiveOptions struct {
+	Header bool // hive.cli.print.header
+
+	// Names, if set, returns the column names to print for the result's,
+	// as Hive names them. Names are lowercased either way.
+	Names func(cols []string) []string
+}
+
+// PrintRowsHive prints query results as the Hive CLI does and returns the
+// number of rows printed.
+func PrintRowsHive(rows *sql.Rows, opts HiveOptions) (int, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.DatabaseTypeName()
+	}
+	counted := &countedRows{resultRows: rows}
+	err = printHive(counted, names, "NULL", opts)
+	return counted.n, err
+}
+
+// PrintValuesHive prints rows computed outside DuckDB as the Hive CLI does
+// and returns the number of rows printed. Nulls, such as the blank cells of
+// DESCRIBE FORMATTED, are printed empty.
+func PrintValuesHive(cols []string, rows [][]any, opts HiveOptions) (int, error) {
+	return len(rows), printHive(&staticRows{cols: cols, rows: rows, pos: -1}, nil, "", opts)
+}
+
+// printHive prints tab-separated rows, after a header of the column names
+// if opts.Header is set. Values are printed as Hive prints them, with null
+// for nulls and JSON-like text for arrays, maps and structs; types holds the
+// DuckDB types of the columns, if known, to tell dates from timestamps.
+func printHive(rows resultRows, types []string, null string, opts HiveOptions) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(os.Stdout)
+	if opts.Header {
+		names := cols
+		if opts.Names != nil {
+			names = opts.Names(cols)
+		}
+		for i, name := range names {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			b.WriteString(strings.ToLower(name))
+		}
+		b.WriteByte('\n')
+	}
+
+	vals := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		for i, v := range vals {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			typ := ""
+			if i < len(types) {
+				typ = types[i]
+			}
+			if v == nil {
+				b.WriteString(null)
+			} else {
+				b.WriteString(hiveValue(v, typ, false))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return b.Flush()
+}
+
+// hiveValue formats a value as Hive prints it. Inside arrays, maps and
+// structs, strings are quoted and nulls are null, as in JSON.
+func hiveValue(v any, typ string, nested bool) string {
+	switch v := v.(type) {
+	case nil:
+		if nested {
+			return "null"
+		}
+		return "NULL"
+	case string:
+		if nested {
+			return strconv.Quote(v)
+		}
+		return v
+	case []byte:
+		return hiveValue(string(v), typ, nested)
+	case float64:
+		return javaDouble(v, 64)
+	case float32:
+		return javaDouble(float64(v), 32)
+	case *big.Int:
+		return v.String()
+	case duckdb.Decimal:
+		return decimalString(v)
+	case duckdb.Interval:
+		return intervalString(v)
+	case time.Time:
+		return timeString(v, typ)
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = hiveValue(item, "", true)
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	case map[string]any:
+		m := make(map[any]any, len(v))
+		for k, item := range v {
+			m[k] = item
+		}
+		return mapString(m)
+	case duckdb.Map:
+		return mapString(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// mapString formats a map or struct as {"key":value,...}, sorted by key as
+// the order of the fields is not known.
+func mapString(m map[any]any) string {
+	type entry struct{ key, value string }
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		key := hiveValue(k, "", true)
+		if _, ok := k.(string); !ok {
+			key = strconv.Quote(key)
+		}
+		entries = append(entries, entry{key, hiveValue(v, "", true)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	items := make([]string, len(entries))
+	for i, e := range entries {
+		items[i] = e.key + ":" + e.value
+	}
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+// javaDouble formats a floating-point number as Java's Double.toString and
+// Float.toString do: 1.0 rather than 1, and 1.0E7 from 10^7 on.
+func javaDouble(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-3 || abs >= 1e7) {
+		s := strconv.FormatFloat(f, 'E', -1, bits)
+		mant, exp, _ := strings.Cut(s, "E")
+		if !strings.Contains(mant, ".") {
+			mant += ".0"
+		}
+		return mant + "E" + strings.TrimPrefix(strings.TrimPrefix(exp, "+"), "0")
+	}
+	s := strconv.FormatFloat(f, 'f', -1, bits)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// decimalString formats a decimal with all the digits of its scale.
+func decimalString(d duckdb.Decimal) string {
+	digits := new(big.Int).Abs(d.Value).String()
+	sign := ""
+	if d.Value.Sign() < 0 {
+		sign = "-"
+	}
+	scale := int(d.Scale)
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// intervalString formats an interval as Hive's year-month (1-2) or
+// day-time (1 02:03:04.000000000) intervals.
+func intervalString(iv duckdb.Interval) string {
+	if iv.Days == 0 && iv.Micros == 0 {
+		return fmt.Sprintf("%d-%d", iv.Months/12, iv.Months%12)
+	}
+	micros := iv.Micros
+	sign := ""
+	if micros < 0 {
+		sign, micros = "-", -micros
+	}
+	secs := micros / 1e6
+	return fmt.Sprintf("%s%d %02d:%02d:%02d.%09d", sign, iv.Days, secs/3600, secs/60%60, secs%60, micros%1e6*1000)
+}
+
+// timeString formats a date, time or timestamp value of the given DuckDB
+// type, with fractional seconds only if there are any, as Hive does.
+func timeString(t time.Time, typ string) string {
+	switch {
+	case typ == "DATE":
+		return t.Format("2006-01-02")
+	case typ == "TIME":
+		return t.Format("15:04:05.999999999")
+	}
+	return t.Format("2006-01-02 15:04:05.999999999")
+}
@@ -20,11 +20,12 @@ const (
 	FormatCSV   Format = "csv"   // RFC 4180 CSV
 	FormatTSV   Format = "tsv"   // Tab-separated values
 	FormatJSON  Format = "json"  // JSON array of objects
+	FormatHive  Format = "hive"  // Hive CLI output: tab-separated, header only if asked for
 )
 
 // ValidFormats returns a list of valid output format names.
 func ValidFormats() []string {
-	return []string{string(FormatTable), string(FormatCSV), string(FormatTSV), string(FormatJSON)}
+	return []string{string(FormatTable), string(FormatCSV), string(FormatTSV), string(FormatJSON), string(FormatHive)}
 }
 
 // ParseFormat parses a format string and returns the corresponding Format.
@@ -38,29 +39,73 @@ func ParseFormat(s string) (Format, error) {
 		return FormatTSV, nil
 	case "json":
 		return FormatJSON, nil
+	case "hive":
+		return FormatHive, nil
 	default:
 		return "", fmt.Errorf("invalid output format %q (valid: %s)", s, strings.Join(ValidFormats(), ", "))
 	}
 }
 
-// PrintRows outputs query results in the specified format.
-func PrintRows(rows *sql.Rows, format Format) error {
+// resultRows is the subset of *sql.Rows used by the printers, so that results
+// computed by hive-duck itself print exactly like DuckDB query results.
+type resultRows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+// PrintRows outputs query results in the specified format and returns the
+// number of rows printed.
+func PrintRows(rows *sql.Rows, format Format) (int, error) {
+	return printResult(rows, format)
+}
+
+// PrintValues outputs rows computed outside DuckDB in the specified format
+// and returns the number of rows printed.
+func PrintValues(cols []string, rows [][]any, format Format) (int, error) {
+	return printResult(&staticRows{cols: cols, rows: rows, pos: -1}, format)
+}
+
+func printResult(rows resultRows, format Format) (int, error) {
+	counted := &countedRows{resultRows: rows}
+	var err error
 	switch format {
-	case FormatTable:
-		return printTable(rows)
 	case FormatCSV:
-		return printCSV(rows, ',')
+		err = printCSV(counted, ',')
 	case FormatTSV:
-		return printCSV(rows, '\t')
+		err = printCSV(counted, '\t')
 	case FormatJSON:
-		return printJSON(rows)
+		err = printJSON(counted)
+	case FormatHive:
+		// Nulls computed by hive-duck are blanks, as in PrintValuesHive
+		null := "NULL"
+		if _, computed := rows.(*staticRows); computed {
+			null = ""
+		}
+		err = printHive(counted, nil, null, HiveOptions{})
 	default:
-		return printTable(rows)
+		err = printTable(counted)
+	}
+	return counted.n, err
+}
+
+// countedRows counts the rows read from a result.
+type countedRows struct {
+	resultRows
+	n int
+}
+
+func (r *countedRows) Next() bool {
+	if !r.resultRows.Next() {
+		return false
 	}
+	r.n++
+	return true
 }
 
 // printTable outputs results as aligned columns (original behavior).
-func printTable(rows *sql.Rows) error {
+func printTable(rows resultRows) error {
 	cols, err := rows.Columns()
 	if err != nil {
 		return err
@@ -105,7 +150,7 @@ func printTable(rows *sql.Rows) error {
 }
 
 // printCSV outputs results as CSV or TSV.
-func printCSV(rows *sql.Rows, delimiter rune) error {
+func printCSV(rows resultRows, delimiter rune) error {
 	cols, err := rows.Columns()
 	if err != nil {
 		return err
@@ -143,7 +188,7 @@ func printCSV(rows *sql.Rows, delimiter rune) error {
 }
 
 // printJSON outputs results as a JSON array of objects.
-func printJSON(rows *sql.Rows) error {
+func printJSON(rows resultRows) error {
 	cols, err := rows.Columns()
 	if err != nil {
 		return err
@@ -202,3 +247,29 @@ func convertJSONValue(v any) any {
 	}
 	return v
 }
+
+// staticRows implements resultRows over in-memory values.
+type staticRows struct {
+	cols []string
+	rows [][]any
+	pos  int
+}
+
+func (r *staticRows) Columns() ([]string, error) { return r.cols, nil }
+
+func (r *staticRows) Next() bool {
+	r.pos++
+	return r.pos < len(r.rows)
+}
+
+func (r *staticRows) Scan(dest ...any) error {
+	if len(dest) != len(r.rows[r.pos]) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(r.rows[r.pos]), len(dest))
+	}
+	for i, v := range r.rows[r.pos] {
+		*dest[i].(*any) = v
+	}
+	return nil
+}
+
+func (r *staticRows) Err() error { return nil }
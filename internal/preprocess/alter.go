@@ -0,0 +1,309 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+)
+
+// alterTablePattern matches the ALTER TABLE forms handled by parseAlterTable.
+// DuckDB's own ALTER TABLE syntax (ADD COLUMN, RENAME COLUMN, ...) does not
+// match and is passed through.
+var alterTablePattern = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+\S+\s+(PARTITION\s*\(.*\)\s*)?(CHANGE|(ADD|REPLACE)\s+COLUMNS|RENAME\s+TO|SET\s+(TBLPROPERTIES|SERDEPROPERTIES|SERDE|LOCATION|FILEFORMAT))\b`)
+
+// ChangeColumn is ALTER TABLE t CHANGE [COLUMN] old new type [COMMENT 'c']
+// [FIRST|AFTER c].
+type ChangeColumn struct {
+	Table  string
+	Old    string
+	Column catalog.Column
+	First  bool
+	After  string
+}
+
+func (c *ChangeColumn) String() string {
+	s := fmt.Sprintf("ALTER TABLE %s CHANGE COLUMN %s %s", c.Table, c.Old, columnDef(c.Column))
+	if c.First {
+		s += " FIRST"
+	} else if c.After != "" {
+		s += " AFTER " + c.After
+	}
+	return s
+}
+
+// AddColumns is ALTER TABLE t ADD COLUMNS (...) or, with Replace,
+// ALTER TABLE t REPLACE COLUMNS (...).
+type AddColumns struct {
+	Table   string
+	Columns []catalog.Column
+	Replace bool
+}
+
+func (c *AddColumns) String() string {
+	op := "ADD"
+	if c.Replace {
+		op = "REPLACE"
+	}
+	defs := make([]string, len(c.Columns))
+	for i, col := range c.Columns {
+		defs[i] = columnDef(col)
+	}
+	return fmt.Sprintf("ALTER TABLE %s %s COLUMNS (%s)", c.Table, op, strings.Join(defs, ", "))
+}
+
+// RenameTable is ALTER TABLE t RENAME TO new.
+type RenameTable struct {
+	Table   string
+	NewName string
+}
+
+func (c *RenameTable) String() string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", c.Table, c.NewName)
+}
+
+// SetTableProperties is ALTER TABLE t SET TBLPROPERTIES (...).
+type SetTableProperties struct {
+	Table      string
+	Properties map[string]string
+}
+
+func (c *SetTableProperties) String() string {
+	return fmt.Sprintf("ALTER TABLE %s SET TBLPROPERTIES %s", c.Table, propertyList(c.Properties))
+}
+
+// SetSerDe is ALTER TABLE t SET SERDE 'class' [WITH SERDEPROPERTIES (...)]
+// or, without a SerDe, ALTER TABLE t SET SERDEPROPERTIES (...).
+type SetSerDe struct {
+	Table      string
+	SerDe      string
+	Properties map[string]string
+}
+
+func (c *SetSerDe) String() string {
+	if c.SerDe == "" {
+		return fmt.Sprintf("ALTER TABLE %s SET SERDEPROPERTIES %s", c.Table, propertyList(c.Properties))
+	}
+	s := fmt.Sprintf("ALTER TABLE %s SET SERDE '%s'", c.Table, c.SerDe)
+	if len(c.Properties) > 0 {
+		s += " WITH SERDEPROPERTIES " + propertyList(c.Properties)
+	}
+	return s
+}
+
+// SetLocation is ALTER TABLE t [PARTITION (...)] SET LOCATION 'path'.
+type SetLocation struct {
+	Table     string
+	Partition PartitionSpec
+	Location  string
+}
+
+func (c *SetLocation) String() string {
+	s := "ALTER TABLE " + c.Table
+	if len(c.Partition) > 0 {
+		s += " " + c.Partition.String()
+	}
+	return fmt.Sprintf("%s SET LOCATION '%s'", s, c.Location)
+}
+
+// SetFileFormat is ALTER TABLE t SET FILEFORMAT fmt, where fmt is a STORED AS
+// name or INPUTFORMAT '...' OUTPUTFORMAT '...' [SERDE '...'].
+type SetFileFormat struct {
+	Table   string
+	Storage catalog.Storage
+}
+
+func (c *SetFileFormat) String() string {
+	st := c.Storage
+	if st.Format != "" {
+		return fmt.Sprintf("ALTER TABLE %s SET FILEFORMAT %s", c.Table, st.Format)
+	}
+	s := fmt.Sprintf("ALTER TABLE %s SET FILEFORMAT INPUTFORMAT '%s' OUTPUTFORMAT '%s'", c.Table, st.InputFormat, st.OutputFormat)
+	if st.SerDe != "" {
+		s += fmt.Sprintf(" SERDE '%s'", st.SerDe)
+	}
+	return s
+}
+
+func columnDef(c catalog.Column) string {
+	s := c.Name + " " + c.Type
+	if c.Comment != "" {
+		s += fmt.Sprintf(" COMMENT '%s'", c.Comment)
+	}
+	return s
+}
+
+// propertyList renders properties as ('k'='v', ...), sorted by key.
+func propertyList(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("'%s'='%s'", k, props[k])
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// parseAlterTable parses the ALTER TABLE forms matched by alterTablePattern.
+func parseAlterTable(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("ALTER", "TABLE"); err != nil {
+		return nil, err
+	}
+	table, err := ts.tableName()
+	if err != nil {
+		return nil, err
+	}
+	var spec PartitionSpec
+	if ts.accept("PARTITION") {
+		if spec, err = parseStaticPartitionSpec(ts); err != nil {
+			return nil, err
+		}
+	}
+
+	var cmd Command
+	switch {
+	case ts.accept("SET", "LOCATION"):
+		c := &SetLocation{Table: table, Partition: spec}
+		if c.Location, err = ts.str(); err != nil {
+			return nil, err
+		}
+		cmd = c
+	case len(spec) > 0:
+		return nil, fmt.Errorf("only SET LOCATION is supported for a single partition")
+
+	case ts.accept("CHANGE"):
+		if cmd, err = parseChangeColumn(ts, table); err != nil {
+			return nil, err
+		}
+
+	case ts.accept("ADD", "COLUMNS"), ts.accept("REPLACE", "COLUMNS"):
+		c := &AddColumns{Table: table, Replace: ts.toks[ts.pos-2].is("REPLACE")}
+		if c.Columns, err = parseColumns(ts); err != nil {
+			return nil, err
+		}
+		if !ts.accept("CASCADE") {
+			ts.accept("RESTRICT")
+		}
+		cmd = c
+
+	case ts.accept("RENAME", "TO"):
+		c := &RenameTable{Table: table}
+		if c.NewName, err = ts.tableName(); err != nil {
+			return nil, err
+		}
+		cmd = c
+
+	case ts.accept("SET", "TBLPROPERTIES"):
+		c := &SetTableProperties{Table: table}
+		if c.Properties, err = ts.properties(); err != nil {
+			return nil, err
+		}
+		cmd = c
+
+	case ts.accept("SET", "SERDEPROPERTIES"):
+		c := &SetSerDe{Table: table}
+		if c.Properties, err = ts.properties(); err != nil {
+			return nil, err
+		}
+		cmd = c
+
+	case ts.accept("SET", "SERDE"):
+		c := &SetSerDe{Table: table}
+		if c.SerDe, err = ts.str(); err != nil {
+			return nil, err
+		}
+		if ts.accept("WITH", "SERDEPROPERTIES") {
+			if c.Properties, err = ts.properties(); err != nil {
+				return nil, err
+			}
+		}
+		cmd = c
+
+	case ts.accept("SET", "FILEFORMAT"):
+		c := &SetFileFormat{Table: table}
+		if ts.accept("INPUTFORMAT") {
+			if c.Storage.InputFormat, err = ts.str(); err != nil {
+				return nil, err
+			}
+			if err := ts.expect("OUTPUTFORMAT"); err != nil {
+				return nil, err
+			}
+			if c.Storage.OutputFormat, err = ts.str(); err != nil {
+				return nil, err
+			}
+			if ts.accept("SERDE") {
+				if c.Storage.SerDe, err = ts.str(); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			f, err := ts.ident()
+			if err != nil {
+				return nil, err
+			}
+			c.Storage.Format = strings.ToUpper(f)
+		}
+		cmd = c
+
+	default:
+		return nil, fmt.Errorf("unsupported ALTER TABLE near %q", truncateStatement(ts.rest(), 40))
+	}
+
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in ALTER TABLE", ts.rest())
+	}
+	return cmd, nil
+}
+
+// parseChangeColumn parses the remainder of ALTER TABLE t CHANGE.
+func parseChangeColumn(ts *tokenStream, table string) (*ChangeColumn, error) {
+	ts.accept("COLUMN")
+	c := &ChangeColumn{Table: table}
+	var err error
+	if c.Old, err = ts.ident(); err != nil {
+		return nil, err
+	}
+
+	// The new definition runs up to a top-level FIRST, AFTER, CASCADE or
+	// RESTRICT; the new name itself may be any word.
+	start := ts.pos
+	ts.next()
+	depth := 0
+	for !ts.done() {
+		t := ts.peek()
+		if depth == 0 && (t.is("FIRST") || t.is("AFTER") || t.is("CASCADE") || t.is("RESTRICT")) {
+			break
+		}
+		switch t.text {
+		case "(", "<":
+			depth++
+		case ")", ">":
+			depth--
+		}
+		ts.next()
+	}
+	if c.Column, err = parseColumnDef(ts, ts.toks[start:ts.pos]); err != nil {
+		return nil, err
+	}
+
+	if ts.accept("FIRST") {
+		c.First = true
+	} else if ts.accept("AFTER") {
+		if c.After, err = ts.ident(); err != nil {
+			return nil, err
+		}
+	}
+	if !ts.accept("CASCADE") {
+		ts.accept("RESTRICT")
+	}
+	return c, nil
+}
@@ -0,0 +1,248 @@
+package preprocess
+
+import "strings"
+
+// SelectColumn is one item of the select list of a query, described as
+// Hive needs it to name the result columns.
+type SelectColumn struct {
+	Table string // alias of the table a column reference or star reads, if known
+	Name  string // column name or alias, empty for an unnamed expression
+	Star  bool   // * or t.*
+}
+
+// fromClauseEnd are the keywords that end the FROM clause of a query.
+var fromClauseEnd = []string{"WHERE", "GROUP", "HAVING", "ORDER", "SORT", "CLUSTER", "DISTRIBUTE",
+	"LIMIT", "QUALIFY", "WINDOW", "UNION", "EXCEPT", "INTERSECT"}
+
+// notAlias are the keywords that can follow a table or end an expression
+// without being an alias.
+var notAlias = []string{"JOIN", "LEFT", "RIGHT", "FULL", "INNER", "OUTER", "CROSS", "NATURAL", "SEMI",
+	"ANTI", "LATERAL", "ON", "USING", "TABLESAMPLE", "END", "NULL", "TRUE", "FALSE"}
+
+// operatorWords are the keywords after which a word is an operand, not an
+// alias.
+var operatorWords = []string{"IS", "NOT", "AND", "OR", "LIKE", "RLIKE", "IN", "BETWEEN", "CASE", "WHEN",
+	"THEN", "ELSE", "DISTINCT", "INTERVAL", "AS"}
+
+// SelectColumns returns the select list of a SELECT query, or nil if stmt
+// is not one. Tables are given for column references and stars over the
+// tables of the FROM clause, or of its only table for unqualified names,
+// but not for grouped queries and set operations, whose columns Hive does
+// not attribute to a table.
+func SelectColumns(stmt string) []SelectColumn {
+	toks, err := tokenize(stmt)
+	if err != nil || len(toks) == 0 {
+		return nil
+	}
+
+	// Skip the common table expressions to the main SELECT.
+	i, depth := 0, 0
+	for ; i < len(toks); i++ {
+		t := toks[i]
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			depth--
+		} else if depth == 0 && t.is("SELECT") {
+			break
+		} else if i == 0 && !t.is("WITH") {
+			return nil
+		}
+	}
+	if i == len(toks) {
+		return nil
+	}
+	i++
+	if i < len(toks) && (toks[i].is("DISTINCT") || toks[i].is("ALL")) {
+		i++
+	}
+
+	var items [][]token
+	start := i
+	for depth = 0; i < len(toks); i++ {
+		t := toks[i]
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			depth--
+		} else if depth == 0 && (t.is(",") || t.is("FROM")) {
+			items = append(items, toks[start:i])
+			start = i + 1
+			if t.is("FROM") {
+				break
+			}
+		}
+	}
+	if i == len(toks) {
+		items = append(items, toks[start:])
+	}
+
+	tables, grouped := fromTables(stmt, toks, i+1)
+	cols := make([]SelectColumn, 0, len(items))
+	for _, item := range items {
+		if len(item) == 0 {
+			return nil
+		}
+		col := selectColumn(stmt, item, tables)
+		if grouped {
+			col.Table = ""
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// fromTables returns the aliases of the tables of the FROM clause starting
+// at toks[i], and whether the query groups rows or is a set operation.
+func fromTables(stmt string, toks []token, i int) ([]string, bool) {
+	var tables []string
+	grouped, inFrom, expect := false, true, true
+	for depth := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.is("("):
+			depth++
+			if depth == 1 && inFrom && expect {
+				// A subquery or a table function: skip to its alias.
+				for depth > 0 && i+1 < len(toks) {
+					i++
+					if toks[i].is("(") {
+						depth++
+					} else if toks[i].is(")") {
+						depth--
+					}
+				}
+				expect = false
+				tables = append(tables, tableAlias(stmt, toks, &i, ""))
+			}
+			continue
+		case t.is(")"):
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case isAnyWord(t, fromClauseEnd):
+			inFrom = false
+			if t.is("GROUP") || t.is("UNION") || t.is("EXCEPT") || t.is("INTERSECT") {
+				grouped = true
+			}
+			continue
+		case !inFrom:
+			continue
+		case t.is(",") || t.is("JOIN"):
+			expect = true
+			continue
+		case t.is("LATERAL"):
+			// LATERAL VIEW adds columns of no table of the FROM clause.
+			tables = append(tables, "")
+			continue
+		}
+		if !expect || t.kind != tokWord {
+			continue
+		}
+		expect = false
+		parts := nameParts(stmt, t)
+		for i+2 < len(toks) && toks[i+1].is(".") && toks[i+2].kind == tokWord {
+			parts = append(parts, nameParts(stmt, toks[i+2])...)
+			i += 2
+		}
+		if i+1 < len(toks) && toks[i+1].is("(") {
+			expect = true // a table function; its group is skipped above
+			continue
+		}
+		tables = append(tables, tableAlias(stmt, toks, &i, parts[len(parts)-1]))
+	}
+	return tables, grouped
+}
+
+// tableAlias returns the alias following the table at toks[*i], or name if
+// there is none, and moves *i past it.
+func tableAlias(stmt string, toks []token, i *int, name string) string {
+	j := *i + 1
+	if j < len(toks) && toks[j].is("AS") {
+		j++
+	}
+	if j < len(toks) && toks[j].kind == tokWord && !isAnyWord(toks[j], fromClauseEnd) && !isAnyWord(toks[j], notAlias) {
+		*i = j
+		return strings.ToLower(toks[j].text)
+	}
+	return strings.ToLower(name)
+}
+
+// selectColumn describes one item of a select list.
+func selectColumn(stmt string, item []token, tables []string) SelectColumn {
+	var col SelectColumn
+	n := len(item)
+	switch {
+	case n >= 3 && item[n-2].is("AS") && item[n-1].kind == tokWord:
+		col.Name, item = item[n-1].text, item[:n-2]
+	case n >= 2 && isAlias(stmt, item[n-1]) && endsOperand(item[n-2]):
+		col.Name, item = item[n-1].text, item[:n-1]
+	}
+	col.Name = strings.ToLower(col.Name)
+
+	sole := ""
+	if len(tables) == 1 {
+		sole = tables[0]
+	}
+	if len(item) == 1 && item[0].is("*") {
+		return SelectColumn{Table: sole, Star: true}
+	}
+	if len(item) == 3 && item[1].is(".") && item[2].is("*") && item[0].kind == tokWord {
+		return SelectColumn{Table: strings.ToLower(item[0].text), Star: true}
+	}
+
+	// A column reference: name, t.name or db.t.name
+	var parts []string
+	for k, t := range item {
+		if k%2 == 1 {
+			if !t.is(".") {
+				return col
+			}
+			continue
+		}
+		if t.kind != tokWord || !isAlias(stmt, t) {
+			return col
+		}
+		parts = append(parts, nameParts(stmt, t)...)
+	}
+	if len(parts) == 0 || len(parts) > 3 || len(item)%2 == 0 {
+		return col
+	}
+	if col.Name == "" {
+		col.Name = strings.ToLower(parts[len(parts)-1])
+	}
+	switch len(parts) {
+	case 1:
+		col.Table = sole
+	default:
+		col.Table = strings.ToLower(parts[len(parts)-2])
+	}
+	return col
+}
+
+// endsOperand reports whether a token can end an expression, so that a
+// name after it is an alias.
+func endsOperand(t token) bool {
+	switch t.kind {
+	case tokString:
+		return true
+	case tokWord:
+		return !isAnyWord(t, operatorWords)
+	}
+	return t.is(")") || t.is("]")
+}
+
+// isAlias reports whether a token is a name that can be an alias: quoted,
+// or starting with a letter or underscore and not a keyword that can end an
+// expression.
+func isAlias(stmt string, t token) bool {
+	if t.kind != tokWord {
+		return false
+	}
+	if stmt[t.start] == '`' {
+		return true
+	}
+	ch := t.text[0]
+	return (ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')) && !isAnyWord(t, notAlias)
+}
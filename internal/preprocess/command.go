@@ -0,0 +1,204 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+)
+
+// Statement is a single rewritten statement. SQL, if set, is passed to DuckDB
+// as-is; Command, if set, is a Hive statement the engine emulates itself and
+// runs after SQL.
+type Statement struct {
+	SQL     string
+	Command Command
+
+	// Origin is the Hive statement of the script the statement was
+	// rewritten from, after variable substitution. The statements
+	// rewritten from one Hive statement share it.
+	Origin *Source
+}
+
+// String renders the statement for --dry-run output.
+func (s Statement) String() string {
+	if s.SQL != "" {
+		return s.SQL
+	}
+	return "-- hive-duck: " + s.Command.String()
+}
+
+// Command is a Hive statement that has no DuckDB equivalent and is emulated
+// by the engine.
+type Command interface {
+	String() string
+}
+
+// CreateTable records the Hive metadata of a new table. For managed tables the
+// DuckDB DDL carried in Statement.SQL creates the table itself.
+type CreateTable struct {
+	Name        string // as written, possibly qualified
+	IfNotExists bool
+	Table       *catalog.Table
+	Query       string // query of a CREATE TABLE ... AS SELECT the engine runs itself
+
+	// Temporary tables are created by the engine, apart from the permanent
+	// tables they shadow, and are dropped at the end of the session.
+	Temporary bool
+}
+
+func (c *CreateTable) String() string {
+	if c.Temporary {
+		return "create temporary table " + c.Name
+	}
+	return fmt.Sprintf("register %s %s", strings.ToLower(c.Table.Type), c.Name)
+}
+
+// LoadData is LOAD DATA [LOCAL] INPATH 'path' [OVERWRITE] INTO TABLE t
+// [PARTITION (...)].
+type LoadData struct {
+	Local     bool
+	Path      string
+	Overwrite bool
+	Table     string
+	Partition PartitionSpec
+}
+
+func (c *LoadData) String() string {
+	var b strings.Builder
+	b.WriteString("LOAD DATA ")
+	if c.Local {
+		b.WriteString("LOCAL ")
+	}
+	fmt.Fprintf(&b, "INPATH '%s' ", c.Path)
+	if c.Overwrite {
+		b.WriteString("OVERWRITE ")
+	}
+	b.WriteString("INTO TABLE " + c.Table)
+	if len(c.Partition) > 0 {
+		b.WriteString(" " + c.Partition.String())
+	}
+	return b.String()
+}
+
+// PartitionValue is one key=value pair of a PARTITION clause. DROP PARTITION
+// also allows comparisons such as ds<'2024-01-01'.
+type PartitionValue struct {
+	Key   string
+	Op    string // "=", "<", "<=", ">", ">=", "<>" or "!=", or "" for a dynamic partition
+	Value string // unquoted
+}
+
+// PartitionSpec is a PARTITION (k=v, ...) clause.
+type PartitionSpec []PartitionValue
+
+// String renders the spec as a Hive PARTITION clause.
+func (p PartitionSpec) String() string {
+	parts := make([]string, len(p))
+	for i, v := range p {
+		if v.Op == "" {
+			// A dynamic partition of an INSERT.
+			parts[i] = v.Key
+			continue
+		}
+		parts[i] = fmt.Sprintf("%s%s'%s'", v.Key, v.Op, strings.ReplaceAll(v.Value, "'", "\\'"))
+	}
+	return "PARTITION (" + strings.Join(parts, ", ") + ")"
+}
+
+// Static reports whether every value of the spec is given with "=".
+func (p PartitionSpec) Static() bool {
+	for _, v := range p {
+		if v.Op != "=" {
+			return false
+		}
+	}
+	return true
+}
+
+// Path renders the spec as a Hive partition directory name such as ds=x/hr=y.
+func (p PartitionSpec) Path() string {
+	parts := make([]string, len(p))
+	for i, v := range p {
+		parts[i] = strings.ToLower(v.Key) + "=" + v.Value
+	}
+	return strings.Join(parts, "/")
+}
+
+var loadDataPattern = regexp.MustCompile(`(?i)^\s*LOAD\s+DATA\b`)
+
+// parseLoadData parses a LOAD DATA statement.
+func parseLoadData(stmt string) (*LoadData, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	c := &LoadData{}
+	if err := ts.expect("LOAD", "DATA"); err != nil {
+		return nil, err
+	}
+	c.Local = ts.accept("LOCAL")
+	if err := ts.expect("INPATH"); err != nil {
+		return nil, err
+	}
+	if c.Path, err = ts.str(); err != nil {
+		return nil, err
+	}
+	c.Overwrite = ts.accept("OVERWRITE")
+	if err := ts.expect("INTO", "TABLE"); err != nil {
+		return nil, err
+	}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if ts.accept("PARTITION") {
+		if c.Partition, err = parseStaticPartitionSpec(ts); err != nil {
+			return nil, err
+		}
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in LOAD DATA", ts.rest())
+	}
+	return c, nil
+}
+
+// parsePartitionSpec parses a parenthesized (k=v, ...) list, allowing
+// comparison operators as DROP PARTITION does.
+func parsePartitionSpec(ts *tokenStream) (PartitionSpec, error) {
+	items, err := ts.group()
+	if err != nil {
+		return nil, err
+	}
+	spec := make(PartitionSpec, 0, len(items))
+	for _, item := range items {
+		if len(item) != 3 || item[0].kind != tokWord || !isComparison(item[1]) || item[2].kind == tokPunct {
+			return nil, fmt.Errorf("expected key=value in partition spec, got %q", ts.rawText(item))
+		}
+		spec = append(spec, PartitionValue{Key: item[0].text, Op: item[1].text, Value: item[2].text})
+	}
+	return spec, nil
+}
+
+// parseStaticPartitionSpec parses a partition spec that must use "=" only.
+func parseStaticPartitionSpec(ts *tokenStream) (PartitionSpec, error) {
+	spec, err := parsePartitionSpec(ts)
+	if err != nil {
+		return nil, err
+	}
+	if !spec.Static() {
+		return nil, fmt.Errorf("partition spec %s must use key=value", spec)
+	}
+	return spec, nil
+}
+
+func isComparison(t token) bool {
+	if t.kind != tokPunct {
+		return false
+	}
+	switch t.text {
+	case "=", "<", "<=", ">", ">=", "<>", "!=":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package preprocess
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// SET key prints the value of key.
+	showConfPattern = regexp.MustCompile(`(?i)^\s*SET\s+([A-Za-z_][A-Za-z0-9_.\-:]*)\s*$`)
+
+	// SET and SET -v list every value.
+	listConfPattern = regexp.MustCompile(`(?i)^\s*SET(\s+-v)?\s*$`)
+
+	resetPattern = regexp.MustCompile(`(?i)^\s*RESET\s*$`)
+)
+
+// SetConf is SET key=value. The key keeps its hiveconf:, hivevar: or
+// system: prefix, if any.
+type SetConf struct {
+	Key   string
+	Value string
+}
+
+func (c *SetConf) String() string {
+	return "SET " + c.Key + "=" + c.Value
+}
+
+// ShowConf is SET key, or SET [-v] with no key to list every value; -v adds
+// the environment.
+type ShowConf struct {
+	Key     string
+	Verbose bool
+}
+
+func (c *ShowConf) String() string {
+	switch {
+	case c.Key != "":
+		return "SET " + c.Key
+	case c.Verbose:
+		return "SET -v"
+	}
+	return "SET"
+}
+
+// ResetConf is RESET, which restores the conf values given on the command
+// line.
+type ResetConf struct{}
+
+func (c *ResetConf) String() string {
+	return "RESET"
+}
+
+// parseSetValue returns the value of SET key=value without a trailing
+// semicolon and surrounding quotes.
+func parseSetValue(s string) string {
+	value := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), ";"))
+	if len(value) >= 2 {
+		if (value[0] == '\'' && value[len(value)-1] == '\'') ||
+			(value[0] == '"' && value[len(value)-1] == '"') {
+			value = value[1 : len(value)-1]
+		}
+	}
+	return value
+}
@@ -0,0 +1,184 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	createDatabasePattern = regexp.MustCompile(`(?i)^\s*CREATE\s+(DATABASE|SCHEMA)\b`)
+	dropDatabasePattern   = regexp.MustCompile(`(?i)^\s*DROP\s+(DATABASE|SCHEMA)\b`)
+	alterDatabasePattern  = regexp.MustCompile(`(?i)^\s*ALTER\s+(DATABASE|SCHEMA)\b`)
+)
+
+// CreateDatabase is CREATE DATABASE|SCHEMA [IF NOT EXISTS] db [COMMENT 'c']
+// [LOCATION 'path'] [WITH DBPROPERTIES (...)].
+type CreateDatabase struct {
+	Name        string
+	IfNotExists bool
+	Comment     string
+	Location    string
+	Properties  map[string]string
+}
+
+func (c *CreateDatabase) String() string {
+	s := "CREATE DATABASE "
+	if c.IfNotExists {
+		s += "IF NOT EXISTS "
+	}
+	s += c.Name
+	if c.Comment != "" {
+		s += fmt.Sprintf(" COMMENT '%s'", c.Comment)
+	}
+	if c.Location != "" {
+		s += fmt.Sprintf(" LOCATION '%s'", c.Location)
+	}
+	if len(c.Properties) > 0 {
+		s += " WITH DBPROPERTIES " + propertyList(c.Properties)
+	}
+	return s
+}
+
+// DropDatabase is DROP DATABASE|SCHEMA [IF EXISTS] db [RESTRICT|CASCADE].
+type DropDatabase struct {
+	Name     string
+	IfExists bool
+	Cascade  bool
+}
+
+func (c *DropDatabase) String() string {
+	s := "DROP DATABASE "
+	if c.IfExists {
+		s += "IF EXISTS "
+	}
+	s += c.Name
+	if c.Cascade {
+		s += " CASCADE"
+	}
+	return s
+}
+
+// AlterDatabase is ALTER DATABASE|SCHEMA db SET DBPROPERTIES (...) or
+// ALTER DATABASE db SET LOCATION 'path'.
+type AlterDatabase struct {
+	Name       string
+	Properties map[string]string
+	Location   string
+}
+
+func (c *AlterDatabase) String() string {
+	if c.Location != "" {
+		return fmt.Sprintf("ALTER DATABASE %s SET LOCATION '%s'", c.Name, c.Location)
+	}
+	return fmt.Sprintf("ALTER DATABASE %s SET DBPROPERTIES %s", c.Name, propertyList(c.Properties))
+}
+
+// UseDatabase is USE db when Hive databases are attached DuckDB databases.
+// The engine checks that the database exists, since it may have been created
+// by an earlier CREATE DATABASE.
+type UseDatabase struct {
+	Name string
+}
+
+func (c *UseDatabase) String() string {
+	return "USE " + c.Name
+}
+
+// parseCreateDatabase parses CREATE DATABASE. DuckDB's CREATE SCHEMA db.s,
+// which names a schema inside an attached database, is passed through.
+func parseCreateDatabase(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	ts.next()
+	ts.next()
+	c := &CreateDatabase{IfNotExists: ts.accept("IF", "NOT", "EXISTS")}
+	if c.Name, err = ts.ident(); err != nil {
+		return nil, err
+	}
+	if strings.Contains(c.Name, ".") {
+		return nil, nil
+	}
+	c.Name = strings.ToLower(c.Name)
+
+	for !ts.done() {
+		switch {
+		case ts.accept("COMMENT"):
+			if c.Comment, err = ts.str(); err != nil {
+				return nil, err
+			}
+		case ts.accept("LOCATION"):
+			if c.Location, err = ts.str(); err != nil {
+				return nil, err
+			}
+		case ts.accept("WITH", "DBPROPERTIES"):
+			if c.Properties, err = ts.properties(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported CREATE DATABASE clause near %q", truncateStatement(ts.rest(), 40))
+		}
+	}
+	return c, nil
+}
+
+// parseDropDatabase parses DROP DATABASE. As with CREATE SCHEMA, qualified
+// DuckDB schema names are passed through.
+func parseDropDatabase(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	ts.next()
+	ts.next()
+	c := &DropDatabase{IfExists: ts.accept("IF", "EXISTS")}
+	if c.Name, err = ts.ident(); err != nil {
+		return nil, err
+	}
+	if strings.Contains(c.Name, ".") {
+		return nil, nil
+	}
+	c.Name = strings.ToLower(c.Name)
+	if ts.accept("CASCADE") {
+		c.Cascade = true
+	} else {
+		ts.accept("RESTRICT")
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in DROP DATABASE", ts.rest())
+	}
+	return c, nil
+}
+
+// parseAlterDatabase parses ALTER DATABASE db SET DBPROPERTIES|LOCATION.
+func parseAlterDatabase(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	ts.next()
+	ts.next()
+	c := &AlterDatabase{}
+	if c.Name, err = ts.ident(); err != nil {
+		return nil, err
+	}
+	c.Name = strings.ToLower(c.Name)
+	switch {
+	case ts.accept("SET", "DBPROPERTIES"):
+		if c.Properties, err = ts.properties(); err != nil {
+			return nil, err
+		}
+	case ts.accept("SET", "LOCATION"):
+		if c.Location, err = ts.str(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported ALTER DATABASE near %q", truncateStatement(ts.rest(), 40))
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in ALTER DATABASE", ts.rest())
+	}
+	return c, nil
+}
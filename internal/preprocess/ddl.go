@@ -0,0 +1,370 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+)
+
+var (
+	createTablePattern = regexp.MustCompile(`(?i)^\s*CREATE\s+(TEMPORARY\s+)?(EXTERNAL\s+)?TABLE\b`)
+	dropTablePattern   = regexp.MustCompile(`(?i)^\s*DROP\s+TABLE\b`)
+)
+
+// DropTable is DROP TABLE [IF EXISTS] t [PURGE]. File-backed tables are
+// DuckDB views, so the engine decides what to drop.
+type DropTable struct {
+	Table    string
+	IfExists bool
+}
+
+func (c *DropTable) String() string {
+	if c.IfExists {
+		return "DROP TABLE IF EXISTS " + c.Table
+	}
+	return "DROP TABLE " + c.Table
+}
+
+// parseDropTable parses DROP TABLE [IF EXISTS] t [PURGE].
+func parseDropTable(stmt string) (*DropTable, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("DROP", "TABLE"); err != nil {
+		return nil, err
+	}
+	c := &DropTable{IfExists: ts.accept("IF", "EXISTS")}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	ts.accept("PURGE")
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in DROP TABLE", ts.rest())
+	}
+	return c, nil
+}
+
+// createTableStmt is a parsed Hive CREATE TABLE statement.
+type createTableStmt struct {
+	name        string // as written, possibly qualified
+	temporary   bool
+	ifNotExists bool
+	asSelect    string // query text of CREATE TABLE ... AS SELECT
+	table       *catalog.Table
+}
+
+// parseCreateTable parses a Hive CREATE TABLE statement, including the
+// PARTITIONED BY, CLUSTERED BY, ROW FORMAT, STORED AS, LOCATION and
+// TBLPROPERTIES clauses that DuckDB does not understand.
+func parseCreateTable(stmt string) (*createTableStmt, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	ct := &createTableStmt{table: &catalog.Table{Type: catalog.ManagedTable}}
+	t := ct.table
+
+	if err := ts.expect("CREATE"); err != nil {
+		return nil, err
+	}
+	ct.temporary = ts.accept("TEMPORARY")
+	if ts.accept("EXTERNAL") {
+		t.Type = catalog.ExternalTable
+	}
+	if err := ts.expect("TABLE"); err != nil {
+		return nil, err
+	}
+	ct.ifNotExists = ts.accept("IF", "NOT", "EXISTS")
+	if ct.name, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+
+	if ts.peek().is("(") {
+		if t.Columns, err = parseColumns(ts); err != nil {
+			return nil, err
+		}
+	}
+
+	for !ts.done() {
+		switch {
+		case ts.accept("COMMENT"):
+			if t.Comment, err = ts.str(); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("PARTITIONED", "BY"):
+			if t.PartitionKeys, err = parseColumns(ts); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("CLUSTERED", "BY"):
+			b := &catalog.Buckets{}
+			if b.Columns, err = parseNameList(ts); err != nil {
+				return nil, err
+			}
+			if ts.accept("SORTED", "BY") {
+				if b.SortBy, err = parseNameList(ts); err != nil {
+					return nil, err
+				}
+			}
+			if err := ts.expect("INTO"); err != nil {
+				return nil, err
+			}
+			if b.Count, err = strconv.Atoi(ts.next().text); err != nil {
+				return nil, fmt.Errorf("invalid bucket count: %w", err)
+			}
+			if err := ts.expect("BUCKETS"); err != nil {
+				return nil, err
+			}
+			t.Buckets = b
+
+		case ts.accept("ROW", "FORMAT"):
+			if err := parseRowFormat(ts, &t.Storage); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("STORED", "AS"):
+			if err := parseStoredAs(ts, &t.Storage); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("LOCATION"):
+			if t.Location, err = ts.str(); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("TBLPROPERTIES"):
+			if t.Properties, err = ts.properties(); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("AS"):
+			ct.asSelect = ts.rest()
+			ts.pos = len(ts.toks)
+
+		default:
+			return nil, fmt.Errorf("unsupported CREATE TABLE clause near %q", truncateStatement(ts.rest(), 40))
+		}
+	}
+
+	if t.Columns == nil && ct.asSelect == "" {
+		return nil, fmt.Errorf("CREATE TABLE %s has no column list", ct.name)
+	}
+	if t.Storage.Format == "" && t.Storage.InputFormat == "" {
+		t.Storage.Format = "TEXTFILE"
+	}
+	return ct, nil
+}
+
+// parseColumns parses a parenthesized list of "name type [COMMENT 'c']"
+// column definitions.
+func parseColumns(ts *tokenStream) ([]catalog.Column, error) {
+	items, err := ts.columnGroup()
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]catalog.Column, 0, len(items))
+	for _, item := range items {
+		col, err := parseColumnDef(ts, item)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// parseColumnDef parses the tokens of one "name type [COMMENT 'c']" column
+// definition.
+func parseColumnDef(ts *tokenStream, item []token) (catalog.Column, error) {
+	if len(item) < 2 || item[0].kind != tokWord {
+		return catalog.Column{}, fmt.Errorf("invalid column definition %q", ts.rawText(item))
+	}
+	col := catalog.Column{Name: item[0].text}
+	typeToks := item[1:]
+	for i, tok := range typeToks {
+		if tok.is("COMMENT") {
+			if i+2 != len(typeToks) || typeToks[i+1].kind != tokString {
+				return col, fmt.Errorf("invalid column comment in %q", ts.rawText(item))
+			}
+			col.Comment = typeToks[i+1].text
+			typeToks = typeToks[:i]
+			break
+		}
+	}
+	typ := strings.Join(strings.Fields(ts.rawText(typeToks)), " ")
+	if _, err := catalog.DuckDBType(typ); err != nil {
+		return col, fmt.Errorf("column %s: %w", col.Name, err)
+	}
+	// Stored as Hive spells it, whatever synonym the DDL used.
+	col.Type, _ = catalog.HiveTypeName(typ)
+	return col, nil
+}
+
+// parseNameList parses a parenthesized list of column names. Sort directions
+// (as in SORTED BY (c DESC)) are kept with the name.
+func parseNameList(ts *tokenStream) ([]string, error) {
+	items, err := ts.group()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		words := make([]string, len(item))
+		for i, tok := range item {
+			words[i] = tok.text
+		}
+		names = append(names, strings.Join(words, " "))
+	}
+	return names, nil
+}
+
+// parseStoredAs parses the remainder of a STORED AS format or STORED AS
+// INPUTFORMAT '...' OUTPUTFORMAT '...' clause.
+func parseStoredAs(ts *tokenStream, s *catalog.Storage) error {
+	var err error
+	if ts.accept("INPUTFORMAT") {
+		if s.InputFormat, err = ts.str(); err != nil {
+			return err
+		}
+		if err := ts.expect("OUTPUTFORMAT"); err != nil {
+			return err
+		}
+		s.OutputFormat, err = ts.str()
+		return err
+	}
+	f, err := ts.ident()
+	if err != nil {
+		return err
+	}
+	s.Format = strings.ToUpper(f)
+	return nil
+}
+
+// parseRowFormat parses the remainder of a ROW FORMAT DELIMITED ... or
+// ROW FORMAT SERDE '...' clause.
+func parseRowFormat(ts *tokenStream, s *catalog.Storage) error {
+	var err error
+	if ts.accept("SERDE") {
+		if s.SerDe, err = ts.str(); err != nil {
+			return err
+		}
+		if ts.accept("WITH", "SERDEPROPERTIES") {
+			if s.SerDeProperties, err = ts.properties(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := ts.expect("DELIMITED"); err != nil {
+		return err
+	}
+	for {
+		var dst *string
+		switch {
+		case ts.accept("FIELDS", "TERMINATED", "BY"):
+			dst = &s.FieldDelim
+		case ts.accept("ESCAPED", "BY"):
+			dst = &s.EscapeDelim
+		case ts.accept("COLLECTION", "ITEMS", "TERMINATED", "BY"):
+			dst = &s.CollectionDelim
+		case ts.accept("MAP", "KEYS", "TERMINATED", "BY"):
+			dst = &s.MapKeyDelim
+		case ts.accept("LINES", "TERMINATED", "BY"):
+			dst = &s.LineDelim
+		case ts.accept("NULL", "DEFINED", "AS"):
+			dst = &s.NullFormat
+		default:
+			return nil
+		}
+		if *dst, err = ts.str(); err != nil {
+			return err
+		}
+	}
+}
+
+// duckDBCreateTable renders the DuckDB DDL for a parsed Hive CREATE TABLE.
+// Partition columns become ordinary trailing columns, as Hive exposes them.
+func duckDBCreateTable(ct *createTableStmt) (string, error) {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if ct.temporary {
+		b.WriteString("TEMPORARY ")
+	}
+	b.WriteString("TABLE ")
+	if ct.ifNotExists {
+		b.WriteString("IF NOT EXISTS ")
+	}
+	b.WriteString(quoteName(ct.name))
+
+	if ct.asSelect != "" {
+		b.WriteString(" AS ")
+		b.WriteString(ct.asSelect)
+		return b.String(), nil
+	}
+
+	b.WriteString(" (")
+	cols := append(append([]catalog.Column{}, ct.table.Columns...), ct.table.PartitionKeys...)
+	for i, col := range cols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		typ, err := catalog.DuckDBType(col.Type)
+		if err != nil {
+			return "", fmt.Errorf("column %s: %w", col.Name, err)
+		}
+		b.WriteString(catalog.QuoteIdent(col.Name) + " " + typ)
+	}
+	b.WriteString(")")
+	return b.String(), nil
+}
+
+// quoteName quotes each part of a possibly qualified name for DuckDB.
+func quoteName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = catalog.QuoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// CreateTarget returns the kind (TABLE, VIEW or DATABASE) and lower-cased
+// name of the object a CREATE statement creates. It returns false for other
+// statements.
+func CreateTarget(stmt string) (kind, name string, ok bool) {
+	ts, err := newTokenStream(stmt)
+	if err != nil || !ts.accept("CREATE") {
+		return "", "", false
+	}
+	ts.accept("OR", "REPLACE")
+	for ts.accept("TEMPORARY") || ts.accept("EXTERNAL") || ts.accept("MATERIALIZED") {
+	}
+	switch {
+	case ts.accept("TABLE"):
+		kind = "TABLE"
+	case ts.accept("VIEW"):
+		kind = "VIEW"
+	case ts.accept("DATABASE"), ts.accept("SCHEMA"):
+		kind = "DATABASE"
+	default:
+		return "", "", false
+	}
+	ts.accept("IF", "NOT", "EXISTS")
+	if name, err = ts.tableName(); err != nil {
+		return "", "", false
+	}
+	return kind, strings.ToLower(name), true
+}
+
+// CheckCreateTable reports why a CREATE TABLE statement cannot be
+// translated, such as an unsupported clause or column type.
+func CheckCreateTable(stmt string) error {
+	_, err := parseCreateTable(stmt)
+	return err
+}
@@ -0,0 +1,141 @@
+package preprocess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Describe is DESCRIBE [EXTENDED|FORMATTED] t [PARTITION (...)] [col]. A
+// column may also be written as DESCRIBE t.col, which the engine tells apart
+// from DESCRIBE db.t by looking the name up.
+type Describe struct {
+	Table     string
+	Column    string
+	Mode      string // "", "EXTENDED" or "FORMATTED"
+	Partition PartitionSpec
+}
+
+func (c *Describe) String() string {
+	parts := []string{"DESCRIBE"}
+	if c.Mode != "" {
+		parts = append(parts, c.Mode)
+	}
+	parts = append(parts, c.Table)
+	if len(c.Partition) > 0 {
+		parts = append(parts, c.Partition.String())
+	}
+	if c.Column != "" {
+		parts = append(parts, c.Column)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ShowTblProperties is SHOW TBLPROPERTIES t [('key')].
+type ShowTblProperties struct {
+	Table string
+	Key   string
+}
+
+func (c *ShowTblProperties) String() string {
+	if c.Key != "" {
+		return fmt.Sprintf("SHOW TBLPROPERTIES %s('%s')", c.Table, c.Key)
+	}
+	return "SHOW TBLPROPERTIES " + c.Table
+}
+
+// ShowCreateTable is SHOW CREATE TABLE t.
+type ShowCreateTable struct {
+	Table string
+}
+
+func (c *ShowCreateTable) String() string {
+	return "SHOW CREATE TABLE " + c.Table
+}
+
+// parseDescribe parses a Hive DESCRIBE of a table or column. Other forms,
+// such as DuckDB's DESCRIBE SELECT ..., are left alone.
+func parseDescribe(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if !ts.accept("DESCRIBE") && !ts.accept("DESC") {
+		return nil, nil
+	}
+	c := &Describe{}
+	for _, mode := range []string{"EXTENDED", "FORMATTED"} {
+		if ts.accept(mode) {
+			c.Mode = mode
+		}
+	}
+	if next := ts.peek(); next.kind != tokWord {
+		return nil, nil
+	}
+	for _, kw := range []string{"SELECT", "WITH", "FROM", "VALUES", "TABLE"} {
+		if ts.peek().is(kw) {
+			return nil, nil
+		}
+	}
+
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if ts.accept("PARTITION") {
+		if c.Partition, err = parseStaticPartitionSpec(ts); err != nil {
+			return nil, err
+		}
+	}
+	if ts.peek().kind == tokWord {
+		c.Column = ts.next().text
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in DESCRIBE", ts.rest())
+	}
+	return c, nil
+}
+
+// parseShowTblProperties parses SHOW TBLPROPERTIES t [('key')].
+func parseShowTblProperties(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("SHOW", "TBLPROPERTIES"); err != nil {
+		return nil, err
+	}
+	c := &ShowTblProperties{}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if ts.accept("(") {
+		if c.Key, err = ts.str(); err != nil {
+			return nil, err
+		}
+		if err := ts.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in SHOW TBLPROPERTIES", ts.rest())
+	}
+	return c, nil
+}
+
+// parseShowCreateTable parses SHOW CREATE TABLE t.
+func parseShowCreateTable(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("SHOW", "CREATE", "TABLE"); err != nil {
+		return nil, err
+	}
+	c := &ShowCreateTable{}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in SHOW CREATE TABLE", ts.rest())
+	}
+	return c, nil
+}
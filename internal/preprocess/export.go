@@ -0,0 +1,123 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	exportPattern = regexp.MustCompile(`(?i)^\s*EXPORT\s+TABLE\b`)
+	importPattern = regexp.MustCompile(`(?i)^\s*IMPORT\s+((EXTERNAL\s+)?TABLE|FROM)\b`)
+)
+
+// ExportTable is EXPORT TABLE t [PARTITION (...)] TO 'path'.
+type ExportTable struct {
+	Table     string
+	Partition PartitionSpec
+	Path      string
+}
+
+func (c *ExportTable) String() string {
+	s := "EXPORT TABLE " + c.Table
+	if len(c.Partition) > 0 {
+		s += " " + c.Partition.String()
+	}
+	return s + fmt.Sprintf(" TO '%s'", c.Path)
+}
+
+// ImportTable is IMPORT [[EXTERNAL] TABLE t [PARTITION (...)]] FROM 'path'
+// [LOCATION 'path']. Without a table name the exported table's name is used.
+type ImportTable struct {
+	Table     string
+	External  bool
+	Partition PartitionSpec
+	Path      string
+	Location  string
+}
+
+func (c *ImportTable) String() string {
+	var b strings.Builder
+	b.WriteString("IMPORT ")
+	if c.External {
+		b.WriteString("EXTERNAL ")
+	}
+	if c.Table != "" {
+		b.WriteString("TABLE " + c.Table + " ")
+	}
+	if len(c.Partition) > 0 {
+		b.WriteString(c.Partition.String() + " ")
+	}
+	fmt.Fprintf(&b, "FROM '%s'", c.Path)
+	if c.Location != "" {
+		fmt.Fprintf(&b, " LOCATION '%s'", c.Location)
+	}
+	return b.String()
+}
+
+func parseExportTable(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("EXPORT", "TABLE"); err != nil {
+		return nil, err
+	}
+	c := &ExportTable{}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if ts.accept("PARTITION") {
+		if c.Partition, err = parseStaticPartitionSpec(ts); err != nil {
+			return nil, err
+		}
+	}
+	if err := ts.expect("TO"); err != nil {
+		return nil, err
+	}
+	if c.Path, err = ts.str(); err != nil {
+		return nil, err
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unsupported EXPORT clause near %q", truncateStatement(ts.rest(), 40))
+	}
+	return c, nil
+}
+
+func parseImportTable(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("IMPORT"); err != nil {
+		return nil, err
+	}
+	c := &ImportTable{External: ts.accept("EXTERNAL")}
+	if ts.accept("TABLE") {
+		if c.Table, err = ts.tableName(); err != nil {
+			return nil, err
+		}
+		if ts.accept("PARTITION") {
+			if c.Partition, err = parseStaticPartitionSpec(ts); err != nil {
+				return nil, err
+			}
+		}
+	} else if c.External {
+		return nil, fmt.Errorf("expected TABLE after IMPORT EXTERNAL near %q", ts.rest())
+	}
+	if err := ts.expect("FROM"); err != nil {
+		return nil, err
+	}
+	if c.Path, err = ts.str(); err != nil {
+		return nil, err
+	}
+	if ts.accept("LOCATION") {
+		if c.Location, err = ts.str(); err != nil {
+			return nil, err
+		}
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unsupported IMPORT clause near %q", truncateStatement(ts.rest(), 40))
+	}
+	return c, nil
+}
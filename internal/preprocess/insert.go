@@ -0,0 +1,132 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	insertPattern = regexp.MustCompile(`(?i)^\s*INSERT\s+(INTO|OVERWRITE)\b`)
+
+	// DuckDB-only INSERT clauses; such statements are passed through.
+	duckDBInsertClause = regexp.MustCompile(`(?i)\b(ON\s+CONFLICT|RETURNING)\b`)
+)
+
+// Insert is INSERT INTO|OVERWRITE [TABLE] t [PARTITION (...)] [(cols)] query.
+// Partition keys given without a value are dynamic: their values are the
+// trailing columns of the query.
+type Insert struct {
+	Table     string
+	Overwrite bool
+	Partition PartitionSpec
+	Columns   []string
+	Query     string
+}
+
+func (c *Insert) String() string {
+	var b strings.Builder
+	if c.Overwrite {
+		b.WriteString("INSERT OVERWRITE TABLE ")
+	} else {
+		b.WriteString("INSERT INTO TABLE ")
+	}
+	b.WriteString(c.Table)
+	if len(c.Partition) > 0 {
+		b.WriteString(" " + c.Partition.String())
+	}
+	if len(c.Columns) > 0 {
+		b.WriteString(" (" + strings.Join(c.Columns, ", ") + ")")
+	}
+	b.WriteString(" " + truncateStatement(c.Query, 60))
+	return b.String()
+}
+
+// parseInsert parses a Hive INSERT statement. Statements using DuckDB's own
+// INSERT syntax, such as INSERT INTO t BY NAME or ON CONFLICT, are passed
+// through.
+func parseInsert(stmt string) (Command, error) {
+	if duckDBInsertClause.MatchString(stmt) {
+		return nil, nil
+	}
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, nil
+	}
+	c := &Insert{}
+	if err := ts.expect("INSERT"); err != nil {
+		return nil, err
+	}
+	c.Overwrite = ts.accept("OVERWRITE")
+	if !c.Overwrite {
+		if err := ts.expect("INTO"); err != nil {
+			return nil, err
+		}
+	}
+	if !ts.accept("TABLE") && c.Overwrite {
+		return nil, fmt.Errorf("expected TABLE after INSERT OVERWRITE near %q", ts.rest())
+	}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, nil
+	}
+	if ts.accept("PARTITION") {
+		if c.Partition, err = parseInsertPartition(ts); err != nil {
+			return nil, err
+		}
+	}
+	if ts.peek().is("(") && !startsQuery(ts.toks[ts.pos+1:]) {
+		items, err := ts.group()
+		if err != nil {
+			return nil, nil
+		}
+		for _, item := range items {
+			if len(item) != 1 || item[0].kind != tokWord {
+				return nil, nil
+			}
+			c.Columns = append(c.Columns, item[0].text)
+		}
+	}
+	if !startsQuery(ts.toks[ts.pos:]) {
+		return nil, nil
+	}
+	c.Query = ts.rest()
+	return c, nil
+}
+
+// startsQuery reports whether toks begin a query: SELECT, VALUES, WITH,
+// FROM or a parenthesized query.
+func startsQuery(toks []token) bool {
+	for len(toks) > 0 && toks[0].is("(") {
+		toks = toks[1:]
+	}
+	if len(toks) == 0 {
+		return false
+	}
+	for _, w := range []string{"SELECT", "VALUES", "WITH", "FROM", "TABLE"} {
+		if toks[0].is(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInsertPartition parses the PARTITION clause of an INSERT, where keys
+// without a value are dynamic partitions.
+func parseInsertPartition(ts *tokenStream) (PartitionSpec, error) {
+	items, err := ts.group()
+	if err != nil {
+		return nil, err
+	}
+	spec := make(PartitionSpec, 0, len(items))
+	for _, item := range items {
+		switch {
+		case len(item) == 1 && item[0].kind == tokWord:
+			spec = append(spec, PartitionValue{Key: item[0].text})
+		case len(item) == 3 && item[0].kind == tokWord && item[1].is("=") && item[2].kind != tokPunct:
+			spec = append(spec, PartitionValue{Key: item[0].text, Op: "=", Value: item[2].text})
+		default:
+			return nil, fmt.Errorf("expected key or key=value in partition spec, got %q", ts.rawText(item))
+		}
+	}
+	return spec, nil
+}
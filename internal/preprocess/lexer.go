@@ -0,0 +1,332 @@
+package preprocess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokWord   tokenKind = iota // keyword, identifier or number
+	tokString                  // '...' or "..." literal
+	tokPunct                   // single punctuation character
+)
+
+// token is a lexical token of a Hive statement. Start and End are byte offsets
+// into the statement so that callers can slice out raw text (types, queries).
+type token struct {
+	kind  tokenKind
+	text  string // word text (backticks removed), unescaped string value, or punctuation
+	start int
+	end   int
+}
+
+// is reports whether the token is the given keyword or punctuation,
+// case-insensitively.
+func (t token) is(s string) bool {
+	return t.kind != tokString && strings.EqualFold(t.text, s)
+}
+
+// tokenize splits a single Hive statement into tokens. Comments are expected
+// to have been removed by SplitStatements.
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+
+		case ch == '\'' || ch == '"':
+			val, end, err := scanString(s, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokString, text: val, start: i, end: end})
+			i = end
+
+		case ch == '`':
+			end := strings.IndexByte(s[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated identifier at offset %d", i)
+			}
+			toks = append(toks, token{kind: tokWord, text: s[i+1 : i+1+end], start: i, end: i + end + 2})
+			i += end + 2
+
+		case isIdentByte(ch):
+			start := i
+			for i < len(s) && (isIdentByte(s[i]) || s[i] == '.' && i+1 < len(s) && isIdentByte(s[i+1])) {
+				i++
+			}
+			toks = append(toks, token{kind: tokWord, text: s[start:i], start: start, end: i})
+
+		default:
+			end := i + 1
+			// Keep two-character comparison operators together.
+			if i+1 < len(s) {
+				switch s[i : i+2] {
+				case "<=", ">=", "<>", "!=":
+					end = i + 2
+				}
+			}
+			toks = append(toks, token{kind: tokPunct, text: s[i:end], start: i, end: end})
+			i = end
+		}
+	}
+	return toks, nil
+}
+
+// scanString scans a quoted literal starting at s[start] and returns its
+// unescaped value and the offset just past the closing quote. Both Hive's
+// backslash escapes and SQL's doubled quotes are accepted.
+func scanString(s string, start int) (string, int, error) {
+	quote := s[start]
+	var b strings.Builder
+	for i := start + 1; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '\\' && i+1 < len(s):
+			n, width := unescapeAt(s, i)
+			b.WriteString(n)
+			i += width - 1
+		case ch == quote && i+1 < len(s) && s[i+1] == quote:
+			b.WriteByte(quote)
+			i++
+		case ch == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string literal at offset %d", start)
+}
+
+// unescapeAt decodes the backslash escape at s[i] and returns the decoded text
+// and the number of bytes consumed. Octal (\001) and unicode (\u0001) escapes
+// are supported since Hive delimiters are commonly written that way.
+func unescapeAt(s string, i int) (string, int) {
+	next := s[i+1]
+	switch next {
+	case 't':
+		return "\t", 2
+	case 'n':
+		return "\n", 2
+	case 'r':
+		return "\r", 2
+	case '0', '1', '2', '3':
+		end := i + 1
+		for end < len(s) && end < i+4 && s[end] >= '0' && s[end] <= '7' {
+			end++
+		}
+		if v, err := strconv.ParseUint(s[i+1:end], 8, 8); err == nil {
+			return string(rune(v)), end - i
+		}
+	case 'u':
+		if i+6 <= len(s) {
+			if v, err := strconv.ParseUint(s[i+2:i+6], 16, 32); err == nil {
+				return string(rune(v)), 6
+			}
+		}
+	}
+	return string(next), 2
+}
+
+func isIdentByte(ch byte) bool {
+	return ch == '_' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// tokenStream is a cursor over tokens used by the Hive statement parsers.
+type tokenStream struct {
+	src  string
+	toks []token
+	pos  int
+}
+
+func newTokenStream(src string) (*tokenStream, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenStream{src: src, toks: toks}, nil
+}
+
+func (ts *tokenStream) done() bool { return ts.pos >= len(ts.toks) }
+
+func (ts *tokenStream) peek() token {
+	if ts.done() {
+		return token{kind: tokPunct, start: len(ts.src), end: len(ts.src)}
+	}
+	return ts.toks[ts.pos]
+}
+
+func (ts *tokenStream) next() token {
+	t := ts.peek()
+	if !ts.done() {
+		ts.pos++
+	}
+	return t
+}
+
+// accept consumes the given sequence of keywords if present.
+func (ts *tokenStream) accept(words ...string) bool {
+	for i, w := range words {
+		if ts.pos+i >= len(ts.toks) || !ts.toks[ts.pos+i].is(w) {
+			return false
+		}
+	}
+	ts.pos += len(words)
+	return true
+}
+
+func (ts *tokenStream) expect(words ...string) error {
+	if !ts.accept(words...) {
+		return fmt.Errorf("expected %s near %q", strings.Join(words, " "), ts.rest())
+	}
+	return nil
+}
+
+func (ts *tokenStream) ident() (string, error) {
+	t := ts.next()
+	if t.kind != tokWord {
+		return "", fmt.Errorf("expected identifier near %q", ts.src[t.start:])
+	}
+	return t.text, nil
+}
+
+func (ts *tokenStream) str() (string, error) {
+	t := ts.next()
+	if t.kind != tokString {
+		return "", fmt.Errorf("expected string literal near %q", ts.src[t.start:])
+	}
+	return t.text, nil
+}
+
+// tableName consumes a possibly qualified table name such as db.tbl or
+// `db`.`tbl`.
+func (ts *tokenStream) tableName() (string, error) {
+	name, err := ts.ident()
+	if err != nil {
+		return "", err
+	}
+	for ts.peek().is(".") {
+		ts.next()
+		part, err := ts.ident()
+		if err != nil {
+			return "", err
+		}
+		name += "." + part
+	}
+	return name, nil
+}
+
+// rest returns the unconsumed source text.
+func (ts *tokenStream) rest() string {
+	return strings.TrimSpace(ts.src[ts.peek().start:])
+}
+
+// group consumes a parenthesized group and returns the token ranges of its
+// top-level comma-separated items. Nested parentheses do not split items.
+func (ts *tokenStream) group() ([][]token, error) {
+	return ts.splitGroup(false)
+}
+
+// columnGroup is like group but also treats angle brackets as nesting, so
+// that column lists with types such as MAP<STRING,INT> split correctly.
+func (ts *tokenStream) columnGroup() ([][]token, error) {
+	return ts.splitGroup(true)
+}
+
+func (ts *tokenStream) splitGroup(angles bool) ([][]token, error) {
+	if err := ts.expect("("); err != nil {
+		return nil, err
+	}
+	var (
+		items [][]token
+		cur   []token
+		depth int
+	)
+	for !ts.done() {
+		t := ts.next()
+		if t.kind == tokPunct {
+			switch t.text {
+			case "(":
+				depth++
+			case "<", ">":
+				if angles && t.text == "<" {
+					depth++
+				} else if angles {
+					depth--
+				}
+			case ")":
+				if depth == 0 {
+					if len(cur) > 0 {
+						items = append(items, cur)
+					}
+					return items, nil
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					items = append(items, cur)
+					cur = nil
+					continue
+				}
+			}
+		}
+		cur = append(cur, t)
+	}
+	return nil, fmt.Errorf("unterminated parenthesis in %q", ts.src)
+}
+
+// properties consumes a ('k'='v', ...) list as used by TBLPROPERTIES and
+// SERDEPROPERTIES.
+func (ts *tokenStream) properties() (map[string]string, error) {
+	items, err := ts.group()
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[string]string, len(items))
+	for _, item := range items {
+		if len(item) != 3 || item[1].text != "=" {
+			return nil, fmt.Errorf("expected 'key'='value' in property list")
+		}
+		props[item[0].text] = item[2].text
+	}
+	return props, nil
+}
+
+// rawText returns the source text spanned by toks.
+func (ts *tokenStream) rawText(toks []token) string {
+	if len(toks) == 0 {
+		return ""
+	}
+	return ts.src[toks[0].start:toks[len(toks)-1].end]
+}
+
+// until consumes tokens up to, but not including, the first of words found
+// outside parentheses and CASE ... END expressions, and returns them.
+func (ts *tokenStream) until(words ...string) []token {
+	start, depth := ts.pos, 0
+	for ; !ts.done(); ts.pos++ {
+		t := ts.toks[ts.pos]
+		switch {
+		case t.is("(") || t.is("CASE"):
+			depth++
+			continue
+		case t.is(")") || t.is("END"):
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+		for _, w := range words {
+			if t.is(w) {
+				return ts.toks[start:ts.pos]
+			}
+		}
+	}
+	return ts.toks[start:ts.pos]
+}
@@ -0,0 +1,182 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+)
+
+var (
+	createMaterializedViewPattern = regexp.MustCompile(`(?i)^\s*CREATE\s+MATERIALIZED\s+VIEW\b`)
+	alterMaterializedViewPattern  = regexp.MustCompile(`(?i)^\s*ALTER\s+MATERIALIZED\s+VIEW\b`)
+	dropMaterializedViewPattern   = regexp.MustCompile(`(?i)^\s*DROP\s+MATERIALIZED\s+VIEW\b`)
+)
+
+// CreateMaterializedView is CREATE MATERIALIZED VIEW [IF NOT EXISTS] mv
+// [DISABLE REWRITE] [COMMENT 'c'] [PARTITIONED ON (...)] [CLUSTERED ON (...)
+// | DISTRIBUTED ON (...) SORTED ON (...)] [ROW FORMAT ...] [STORED AS ...]
+// [LOCATION '...'] [TBLPROPERTIES (...)] AS query. Table holds the declared
+// metadata; the partition keys have no types until the query runs.
+type CreateMaterializedView struct {
+	Name        string
+	IfNotExists bool
+	Table       *catalog.Table
+	Query       string
+}
+
+func (c *CreateMaterializedView) String() string {
+	s := "CREATE MATERIALIZED VIEW "
+	if c.IfNotExists {
+		s += "IF NOT EXISTS "
+	}
+	return s + c.Name + " AS " + truncateStatement(c.Query, 60)
+}
+
+// AlterMaterializedView is ALTER MATERIALIZED VIEW mv REBUILD|ENABLE
+// REWRITE|DISABLE REWRITE.
+type AlterMaterializedView struct {
+	Name   string
+	Action string // REBUILD, ENABLE REWRITE or DISABLE REWRITE
+}
+
+func (c *AlterMaterializedView) String() string {
+	return "ALTER MATERIALIZED VIEW " + c.Name + " " + c.Action
+}
+
+// DropMaterializedView is DROP MATERIALIZED VIEW [IF EXISTS] mv.
+type DropMaterializedView struct {
+	Name     string
+	IfExists bool
+}
+
+func (c *DropMaterializedView) String() string {
+	if c.IfExists {
+		return "DROP MATERIALIZED VIEW IF EXISTS " + c.Name
+	}
+	return "DROP MATERIALIZED VIEW " + c.Name
+}
+
+// parseCreateMaterializedView parses a CREATE MATERIALIZED VIEW statement.
+// Clustering and distribution clauses only guide Hive's storage layout and
+// are skipped.
+func parseCreateMaterializedView(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("CREATE", "MATERIALIZED", "VIEW"); err != nil {
+		return nil, err
+	}
+	c := &CreateMaterializedView{IfNotExists: ts.accept("IF", "NOT", "EXISTS")}
+	if c.Name, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	t := &catalog.Table{Type: catalog.MaterializedView, Materialized: &catalog.Materialization{RewriteEnabled: true}}
+	c.Table = t
+
+	for c.Query == "" {
+		switch {
+		case ts.accept("DISABLE", "REWRITE"):
+			t.Materialized.RewriteEnabled = false
+
+		case ts.accept("COMMENT"):
+			if t.Comment, err = ts.str(); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("PARTITIONED", "ON"):
+			names, err := parseNameList(ts)
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range names {
+				t.PartitionKeys = append(t.PartitionKeys, catalog.Column{Name: strings.ToLower(name)})
+			}
+
+		case ts.accept("CLUSTERED", "ON"), ts.accept("DISTRIBUTED", "ON"), ts.accept("SORTED", "ON"):
+			if _, err := parseNameList(ts); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("ROW", "FORMAT"):
+			if err := parseRowFormat(ts, &t.Storage); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("STORED", "AS"):
+			if err := parseStoredAs(ts, &t.Storage); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("LOCATION"):
+			if t.Location, err = ts.str(); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("TBLPROPERTIES"):
+			if t.Properties, err = ts.properties(); err != nil {
+				return nil, err
+			}
+
+		case ts.accept("AS"):
+			if c.Query = ts.rest(); c.Query == "" {
+				return nil, fmt.Errorf("CREATE MATERIALIZED VIEW %s has no query", c.Name)
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported CREATE MATERIALIZED VIEW clause near %q", truncateStatement(ts.rest(), 40))
+		}
+	}
+
+	if t.Storage.Format == "" && t.Storage.InputFormat == "" {
+		t.Storage.Format = "TEXTFILE"
+	}
+	return c, nil
+}
+
+// parseAlterMaterializedView parses ALTER MATERIALIZED VIEW mv REBUILD and
+// ALTER MATERIALIZED VIEW mv ENABLE|DISABLE REWRITE.
+func parseAlterMaterializedView(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("ALTER", "MATERIALIZED", "VIEW"); err != nil {
+		return nil, err
+	}
+	c := &AlterMaterializedView{}
+	if c.Name, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	for _, action := range [][]string{{"REBUILD"}, {"ENABLE", "REWRITE"}, {"DISABLE", "REWRITE"}} {
+		if ts.accept(action...) {
+			c.Action = strings.Join(action, " ")
+			break
+		}
+	}
+	if c.Action == "" || !ts.done() {
+		return nil, fmt.Errorf("expected REBUILD, ENABLE REWRITE or DISABLE REWRITE near %q", ts.rest())
+	}
+	return c, nil
+}
+
+// parseDropMaterializedView parses DROP MATERIALIZED VIEW [IF EXISTS] mv.
+func parseDropMaterializedView(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("DROP", "MATERIALIZED", "VIEW"); err != nil {
+		return nil, err
+	}
+	c := &DropMaterializedView{IfExists: ts.accept("IF", "EXISTS")}
+	if c.Name, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in DROP MATERIALIZED VIEW", ts.rest())
+	}
+	return c, nil
+}
@@ -0,0 +1,212 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var mergePattern = regexp.MustCompile(`(?i)^\s*MERGE\s+INTO\b`)
+
+// Merge is MERGE INTO target [[AS] t] USING source [[AS] s] ON condition
+// followed by WHEN [NOT] MATCHED clauses. Source is a table name or a
+// parenthesized query; conditions and expressions are kept as SQL text.
+type Merge struct {
+	Target      string
+	TargetAlias string
+	Source      string
+	SourceAlias string
+	On          string
+	Clauses     []MergeClause
+}
+
+// MergeClause is one WHEN clause of a MERGE: WHEN MATCHED [AND cond] THEN
+// UPDATE SET ... | DELETE, or WHEN NOT MATCHED [AND cond] THEN INSERT
+// [(cols)] VALUES (...).
+type MergeClause struct {
+	Matched   bool
+	Condition string
+	Action    string // UPDATE, DELETE or INSERT
+	Set       []MergeAssignment
+	Columns   []string
+	Values    []string
+}
+
+// MergeAssignment is one column = expression of WHEN MATCHED THEN UPDATE.
+type MergeAssignment struct {
+	Column string
+	Expr   string
+}
+
+func (c *Merge) String() string {
+	actions := make([]string, len(c.Clauses))
+	for i, cl := range c.Clauses {
+		actions[i] = cl.Action
+	}
+	return fmt.Sprintf("MERGE INTO %s USING %s ON %s (%s)",
+		c.Target, truncateStatement(c.Source, 40), truncateStatement(c.On, 40), strings.Join(actions, ", "))
+}
+
+// parseMerge parses a Hive MERGE statement. As in Hive, there can be one
+// WHEN MATCHED clause per action, the first of two needing a condition, and
+// one WHEN NOT MATCHED clause.
+func parseMerge(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("MERGE", "INTO"); err != nil {
+		return nil, err
+	}
+	c := &Merge{}
+	if c.Target, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if c.TargetAlias, err = mergeAlias(ts, "USING"); err != nil {
+		return nil, err
+	}
+	if err := ts.expect("USING"); err != nil {
+		return nil, err
+	}
+	if ts.peek().is("(") {
+		start := ts.pos
+		if _, err := ts.group(); err != nil {
+			return nil, err
+		}
+		c.Source = ts.rawText(ts.toks[start:ts.pos])
+	} else if c.Source, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if c.SourceAlias, err = mergeAlias(ts, "ON"); err != nil {
+		return nil, err
+	}
+	if err := ts.expect("ON"); err != nil {
+		return nil, err
+	}
+	if c.On = ts.rawText(ts.until("WHEN")); c.On == "" {
+		return nil, fmt.Errorf("MERGE INTO %s has no ON condition", c.Target)
+	}
+
+	for ts.accept("WHEN") {
+		cl, err := parseMergeClause(ts)
+		if err != nil {
+			return nil, err
+		}
+		c.Clauses = append(c.Clauses, cl)
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("expected WHEN near %q", truncateStatement(ts.rest(), 40))
+	}
+	if err := checkMergeClauses(c.Clauses); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// mergeAlias consumes the optional [AS] alias that precedes next.
+func mergeAlias(ts *tokenStream, next string) (string, error) {
+	if ts.accept("AS") || (ts.peek().kind == tokWord && !ts.peek().is(next)) {
+		return ts.ident()
+	}
+	return "", nil
+}
+
+func parseMergeClause(ts *tokenStream) (MergeClause, error) {
+	var cl MergeClause
+	if ts.accept("NOT", "MATCHED") {
+		cl.Matched = false
+	} else if ts.accept("MATCHED") {
+		cl.Matched = true
+	} else {
+		return cl, fmt.Errorf("expected MATCHED or NOT MATCHED near %q", truncateStatement(ts.rest(), 40))
+	}
+	if ts.accept("AND") {
+		if cl.Condition = ts.rawText(ts.until("THEN")); cl.Condition == "" {
+			return cl, fmt.Errorf("missing condition after WHEN ... AND")
+		}
+	}
+	if err := ts.expect("THEN"); err != nil {
+		return cl, err
+	}
+
+	switch {
+	case cl.Matched && ts.accept("UPDATE", "SET"):
+		cl.Action = "UPDATE"
+		for {
+			item := ts.until("WHEN", ",")
+			eq := -1
+			for i, t := range item {
+				if t.is("=") {
+					eq = i
+					break
+				}
+			}
+			if eq < 1 || eq == len(item)-1 || item[eq-1].kind != tokWord {
+				return cl, fmt.Errorf("expected column = expression in UPDATE SET, got %q", ts.rawText(item))
+			}
+			// Hive allows no qualified names here; t.col is taken as col.
+			cl.Set = append(cl.Set, MergeAssignment{Column: item[eq-1].text, Expr: ts.rawText(item[eq+1:])})
+			if !ts.accept(",") {
+				break
+			}
+		}
+
+	case cl.Matched && ts.accept("DELETE"):
+		cl.Action = "DELETE"
+
+	case !cl.Matched && ts.accept("INSERT"):
+		cl.Action = "INSERT"
+		if ts.peek().is("(") {
+			var err error
+			if cl.Columns, err = parseNameList(ts); err != nil {
+				return cl, err
+			}
+		}
+		if err := ts.expect("VALUES"); err != nil {
+			return cl, err
+		}
+		items, err := ts.group()
+		if err != nil {
+			return cl, err
+		}
+		for _, item := range items {
+			cl.Values = append(cl.Values, ts.rawText(item))
+		}
+		if len(cl.Columns) > 0 && len(cl.Columns) != len(cl.Values) {
+			return cl, fmt.Errorf("MERGE INSERT lists %d columns but %d values", len(cl.Columns), len(cl.Values))
+		}
+
+	case cl.Matched:
+		return cl, fmt.Errorf("expected UPDATE SET or DELETE after WHEN MATCHED THEN near %q", truncateStatement(ts.rest(), 40))
+	default:
+		return cl, fmt.Errorf("expected INSERT after WHEN NOT MATCHED THEN near %q", truncateStatement(ts.rest(), 40))
+	}
+	return cl, nil
+}
+
+// checkMergeClauses applies Hive's rules on the WHEN clauses of a MERGE.
+func checkMergeClauses(clauses []MergeClause) error {
+	if len(clauses) == 0 {
+		return fmt.Errorf("MERGE needs at least one WHEN clause")
+	}
+	var matched []MergeClause
+	inserts := 0
+	for _, cl := range clauses {
+		if cl.Matched {
+			matched = append(matched, cl)
+		} else {
+			inserts++
+		}
+	}
+	switch {
+	case inserts > 1:
+		return fmt.Errorf("MERGE can have only one WHEN NOT MATCHED clause")
+	case len(matched) > 2:
+		return fmt.Errorf("MERGE can have at most two WHEN MATCHED clauses")
+	case len(matched) == 2 && matched[0].Action == matched[1].Action:
+		return fmt.Errorf("MERGE can have only one WHEN MATCHED ... THEN %s clause", matched[0].Action)
+	case len(matched) == 2 && matched[0].Condition == "":
+		return fmt.Errorf("the first of two WHEN MATCHED clauses must have an AND condition")
+	}
+	return nil
+}
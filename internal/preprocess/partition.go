@@ -0,0 +1,232 @@
+package preprocess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PartitionDef is a partition added by ALTER TABLE ... ADD PARTITION.
+type PartitionDef struct {
+	Spec     PartitionSpec
+	Location string // optional LOCATION clause
+}
+
+// AddPartitions is ALTER TABLE t ADD [IF NOT EXISTS] PARTITION (...)
+// [LOCATION '...'] ...
+type AddPartitions struct {
+	Table       string
+	IfNotExists bool
+	Partitions  []PartitionDef
+}
+
+func (c *AddPartitions) String() string {
+	var b strings.Builder
+	b.WriteString("ALTER TABLE " + c.Table + " ADD")
+	if c.IfNotExists {
+		b.WriteString(" IF NOT EXISTS")
+	}
+	for _, p := range c.Partitions {
+		b.WriteString(" " + p.Spec.String())
+		if p.Location != "" {
+			fmt.Fprintf(&b, " LOCATION '%s'", p.Location)
+		}
+	}
+	return b.String()
+}
+
+// DropPartitions is ALTER TABLE t DROP [IF EXISTS] PARTITION (...), ...
+// Specs may use comparisons, e.g. PARTITION (ds<'2024-01-01').
+type DropPartitions struct {
+	Table    string
+	IfExists bool
+	Specs    []PartitionSpec
+}
+
+func (c *DropPartitions) String() string {
+	specs := make([]string, len(c.Specs))
+	for i, s := range c.Specs {
+		specs[i] = s.String()
+	}
+	ifExists := ""
+	if c.IfExists {
+		ifExists = " IF EXISTS"
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP%s %s", c.Table, ifExists, strings.Join(specs, ", "))
+}
+
+// RepairTable is MSCK REPAIR TABLE t [ADD|DROP|SYNC PARTITIONS].
+type RepairTable struct {
+	Table string
+	Mode  string // ADD, DROP or SYNC
+}
+
+func (c *RepairTable) String() string {
+	return fmt.Sprintf("MSCK REPAIR TABLE %s %s PARTITIONS", c.Table, c.Mode)
+}
+
+// ShowPartitions is SHOW PARTITIONS t [PARTITION (...)].
+type ShowPartitions struct {
+	Table     string
+	Partition PartitionSpec
+}
+
+func (c *ShowPartitions) String() string {
+	s := "SHOW PARTITIONS " + c.Table
+	if len(c.Partition) > 0 {
+		s += " " + c.Partition.String()
+	}
+	return s
+}
+
+// TruncateTable is TRUNCATE TABLE t [PARTITION (...)].
+type TruncateTable struct {
+	Table     string
+	Partition PartitionSpec
+}
+
+func (c *TruncateTable) String() string {
+	s := "TRUNCATE TABLE " + c.Table
+	if len(c.Partition) > 0 {
+		s += " " + c.Partition.String()
+	}
+	return s
+}
+
+// parseAlterPartitions parses ALTER TABLE ... ADD PARTITION and
+// ALTER TABLE ... DROP PARTITION.
+func parseAlterPartitions(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("ALTER", "TABLE"); err != nil {
+		return nil, err
+	}
+	table, err := ts.tableName()
+	if err != nil {
+		return nil, err
+	}
+
+	if ts.accept("ADD") {
+		c := &AddPartitions{Table: table, IfNotExists: ts.accept("IF", "NOT", "EXISTS")}
+		for ts.accept("PARTITION") {
+			var p PartitionDef
+			if p.Spec, err = parseStaticPartitionSpec(ts); err != nil {
+				return nil, err
+			}
+			if ts.accept("LOCATION") {
+				if p.Location, err = ts.str(); err != nil {
+					return nil, err
+				}
+			}
+			c.Partitions = append(c.Partitions, p)
+		}
+		if len(c.Partitions) == 0 || !ts.done() {
+			return nil, fmt.Errorf("expected PARTITION (...) [LOCATION '...'] near %q", ts.rest())
+		}
+		return c, nil
+	}
+
+	if err := ts.expect("DROP"); err != nil {
+		return nil, err
+	}
+	c := &DropPartitions{Table: table, IfExists: ts.accept("IF", "EXISTS")}
+	for {
+		if err := ts.expect("PARTITION"); err != nil {
+			return nil, err
+		}
+		spec, err := parsePartitionSpec(ts)
+		if err != nil {
+			return nil, err
+		}
+		c.Specs = append(c.Specs, spec)
+		if !ts.accept(",") {
+			break
+		}
+	}
+	// Data of emulated tables is never moved to a trash directory, so
+	// IGNORE PROTECTION and PURGE make no difference.
+	ts.accept("IGNORE", "PROTECTION")
+	ts.accept("PURGE")
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in ALTER TABLE DROP PARTITION", ts.rest())
+	}
+	return c, nil
+}
+
+// parseRepairTable parses MSCK [REPAIR] TABLE t [ADD|DROP|SYNC PARTITIONS].
+func parseRepairTable(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("MSCK"); err != nil {
+		return nil, err
+	}
+	ts.accept("REPAIR")
+	if err := ts.expect("TABLE"); err != nil {
+		return nil, err
+	}
+	c := &RepairTable{Mode: "ADD"}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	for _, mode := range []string{"ADD", "DROP", "SYNC"} {
+		if ts.accept(mode, "PARTITIONS") {
+			c.Mode = mode
+		}
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in MSCK REPAIR TABLE", ts.rest())
+	}
+	return c, nil
+}
+
+// parseShowPartitions parses SHOW PARTITIONS t [PARTITION (...)].
+func parseShowPartitions(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("SHOW", "PARTITIONS"); err != nil {
+		return nil, err
+	}
+	c := &ShowPartitions{}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if ts.accept("PARTITION") {
+		if c.Partition, err = parseStaticPartitionSpec(ts); err != nil {
+			return nil, err
+		}
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in SHOW PARTITIONS", ts.rest())
+	}
+	return c, nil
+}
+
+// parseTruncateTable parses TRUNCATE TABLE t [PARTITION (...)].
+func parseTruncateTable(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("TRUNCATE"); err != nil {
+		return nil, err
+	}
+	ts.accept("TABLE")
+	c := &TruncateTable{}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if ts.accept("PARTITION") {
+		if c.Partition, err = parseStaticPartitionSpec(ts); err != nil {
+			return nil, err
+		}
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in TRUNCATE TABLE", ts.rest())
+	}
+	return c, nil
+}
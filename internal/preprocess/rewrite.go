@@ -5,12 +5,13 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/danieljhkim/hive-duck/internal/catalog"
 	"github.com/danieljhkim/hive-duck/internal/config"
 )
 
 // RewriteResult contains the output of the Hive-to-DuckDB rewrite process.
 type RewriteResult struct {
-	Statements    []string          // Rewritten SQL statements to execute
+	Statements    []Statement       // Rewritten statements to execute
 	CurrentSchema string            // Last schema set via USE statement
 	SetVars       map[string]string // Captured SET k=v pairs (for reference)
 }
@@ -18,6 +19,15 @@ type RewriteResult struct {
 // RewriteOptions configures the rewrite behavior.
 type RewriteOptions struct {
 	DatabaseMap *config.DatabaseMap // If set, USE statements target attached databases
+
+	// WarehouseTables keeps managed tables stored as PARQUET as Parquet
+	// files in the warehouse directory instead of DuckDB tables.
+	WarehouseTables bool
+
+	// Config, if set, substitutes ${hiveconf:}, ${hivevar:}, ${system:} and
+	// ${env:} references statement by statement, with the values of the
+	// SET and RESET statements before them. Config itself is not changed.
+	Config *config.Config
 }
 
 // Regex patterns for Hive statements
@@ -29,79 +39,251 @@ var (
 	usePattern = regexp.MustCompile(`(?i)^\s*USE\s+([A-Za-z0-9_]+)\s*$`)
 )
 
+// commandParsers map Hive statements that the engine emulates to their parsers.
+var commandParsers = []struct {
+	pattern *regexp.Regexp
+	parse   func(string) (Command, error)
+}{
+	{createDatabasePattern, parseCreateDatabase},
+	{dropDatabasePattern, parseDropDatabase},
+	{alterDatabasePattern, parseAlterDatabase},
+	{loadDataPattern, func(s string) (Command, error) { return parseLoadData(s) }},
+	{insertPattern, parseInsert},
+	{mergePattern, parseMerge},
+	{exportPattern, parseExportTable},
+	{importPattern, parseImportTable},
+	{createMaterializedViewPattern, parseCreateMaterializedView},
+	{alterMaterializedViewPattern, parseAlterMaterializedView},
+	{dropMaterializedViewPattern, parseDropMaterializedView},
+	{regexp.MustCompile(`(?i)^\s*ALTER\s+TABLE\s+\S+\s+(ADD|DROP)\s+(IF\s+(NOT\s+)?EXISTS\s+)?PARTITION\b`), parseAlterPartitions},
+	{alterTablePattern, parseAlterTable},
+	{regexp.MustCompile(`(?i)^\s*MSCK\b`), parseRepairTable},
+	{regexp.MustCompile(`(?i)^\s*SHOW\s+PARTITIONS\b`), parseShowPartitions},
+	{regexp.MustCompile(`(?i)^\s*TRUNCATE\b`), parseTruncateTable},
+	{regexp.MustCompile(`(?i)^\s*DESC(RIBE)?\s+FUNCTION\b`), parseDescribeFunction},
+	{regexp.MustCompile(`(?i)^\s*DESC(RIBE)?\s+(DATABASE|SCHEMA)\b`), parseDescribeDatabase},
+	{regexp.MustCompile(`(?i)^\s*DESC(RIBE)?\b`), parseDescribe},
+	{regexp.MustCompile(`(?i)^\s*SHOW\s+TBLPROPERTIES\b`), parseShowTblProperties},
+	{regexp.MustCompile(`(?i)^\s*SHOW\s+CREATE\s+TABLE\b`), parseShowCreateTable},
+	{regexp.MustCompile(`(?i)^\s*SHOW\s+(DATABASES|SCHEMAS)\b`), parseShowDatabases},
+	{regexp.MustCompile(`(?i)^\s*SHOW\s+(TABLES|VIEWS|MATERIALIZED\s+VIEWS)\b`), parseShowTables},
+	{regexp.MustCompile(`(?i)^\s*SHOW\s+COLUMNS\b`), parseShowColumns},
+	{regexp.MustCompile(`(?i)^\s*SHOW\s+FUNCTIONS\b`), parseShowFunctions},
+}
+
 // Rewrite transforms Hive SQL statements into DuckDB-compatible statements.
-// - SET k=v statements are captured but not executed
+// - SET k=v statements are captured, and become commands like SET k, SET
+// [-v] and RESET, as the engine keeps the conf values of the session
 // - USE db statements are rewritten based on options:
-//   - With DatabaseMap: USE db (databases are pre-ATTACHed)
+//
+//   - With DatabaseMap: USE db of an attached database, checked by the engine
+//
 //   - Without DatabaseMap: CREATE SCHEMA IF NOT EXISTS + SET search_path (legacy)
-func Rewrite(stmts []string, opts *RewriteOptions) (*RewriteResult, error) {
+//
+//   - CREATE/DROP/ALTER DATABASE become commands that attach DuckDB files
+//     with a DatabaseMap, or manage schemas in legacy mode
+//
+//   - CREATE TABLE statements have their Hive clauses translated or recorded;
+//     CREATE TEMPORARY TABLE becomes a command, as the engine keeps temporary
+//     tables apart
+//
+//   - INSERT INTO/OVERWRITE [TABLE] statements become commands, as the engine
+//     writes file-backed tables and emulates partition overwrites
+//
+//   - LOAD DATA, partition management and other statements listed in
+//     commandParsers become commands emulated by the engine
+func Rewrite(stmts []Source, opts *RewriteOptions) (*RewriteResult, error) {
 	result := &RewriteResult{
-		Statements: make([]string, 0, len(stmts)),
+		Statements: make([]Statement, 0, len(stmts)),
 		SetVars:    make(map[string]string),
 	}
 
 	if opts == nil {
 		opts = &RewriteOptions{}
 	}
+	var cfg *config.Config
+	if opts.Config != nil {
+		cfg = opts.Config.Clone()
+	}
 
 	for _, stmt := range stmts {
-		trimmed := strings.TrimSpace(stmt)
-		if trimmed == "" {
+		src := stmt.trimSpace()
+		if src.SQL == "" {
 			continue
 		}
+		// Like Hive, substitute each statement with the values set so far
+		if cfg != nil {
+			var err error
+			if src, err = SubstituteSource(src, cfg); err != nil {
+				return nil, err
+			}
+			if src = src.trimSpace(); src.SQL == "" {
+				continue
+			}
+		}
 
-		// Check for SET statement
-		if matches := setPattern.FindStringSubmatch(trimmed); matches != nil {
-			key := strings.TrimSpace(matches[1])
-			value := strings.TrimSpace(matches[2])
-			// Remove trailing semicolon if present
-			value = strings.TrimSuffix(value, ";")
-			value = strings.TrimSpace(value)
-			// Remove surrounding quotes if present
-			if len(value) >= 2 {
-				if (value[0] == '\'' && value[len(value)-1] == '\'') ||
-					(value[0] == '"' && value[len(value)-1] == '"') {
-					value = value[1 : len(value)-1]
-				}
+		origin := &src
+		first := len(result.Statements)
+		if err := result.rewrite(src.SQL, opts, cfg); err != nil {
+			if pos := src.Start(); pos.IsValid() {
+				return nil, fmt.Errorf("%s: %w", pos, err)
 			}
-			result.SetVars[key] = value
-			// SET statements are captured but not passed to DuckDB
-			continue
+			return nil, err
 		}
+		for i := first; i < len(result.Statements); i++ {
+			result.Statements[i].Origin = origin
+		}
+	}
+
+	return result, nil
+}
 
-		// Check for USE statement
-		if matches := usePattern.FindStringSubmatch(trimmed); matches != nil {
-			dbName := strings.TrimSpace(matches[1])
-			result.CurrentSchema = dbName
-
-			if opts.DatabaseMap != nil {
-				// Database mapping mode: verify DB is mapped, then USE it
-				if !opts.DatabaseMap.HasDatabase(dbName) {
-					return nil, fmt.Errorf("database %q not found in config (available: %v)",
-						dbName, opts.DatabaseMap.DatabaseNames())
-				}
-				// Pass through as DuckDB USE (databases are pre-ATTACHed)
-				result.Statements = append(result.Statements, "USE "+dbName)
-			} else {
-				// Legacy mode: create schema and set search_path
-				result.Statements = append(result.Statements,
-					"CREATE SCHEMA IF NOT EXISTS "+dbName)
-				result.Statements = append(result.Statements,
-					"SET search_path = '"+dbName+"'")
+// rewrite appends the statements a Hive statement is rewritten to.
+func (r *RewriteResult) rewrite(trimmed string, opts *RewriteOptions, cfg *config.Config) error {
+	// Check for SET statement
+	if matches := setPattern.FindStringSubmatch(trimmed); matches != nil {
+		key := strings.TrimSpace(matches[1])
+		value := parseSetValue(matches[2])
+		r.SetVars[key] = value
+		if cfg != nil {
+			if err := cfg.Set(key, value); err != nil {
+				return fmt.Errorf("%s: %w", truncateStatement(trimmed, 40), err)
 			}
-			continue
 		}
+		// SET statements are not passed to DuckDB
+		r.Statements = append(r.Statements, Statement{Command: &SetConf{Key: key, Value: value}})
+		return nil
+	}
+	if matches := showConfPattern.FindStringSubmatch(trimmed); matches != nil {
+		r.Statements = append(r.Statements, Statement{Command: &ShowConf{Key: matches[1]}})
+		return nil
+	}
+	if matches := listConfPattern.FindStringSubmatch(trimmed); matches != nil {
+		r.Statements = append(r.Statements, Statement{Command: &ShowConf{Verbose: matches[1] != ""}})
+		return nil
+	}
+	if resetPattern.MatchString(trimmed) {
+		if cfg != nil {
+			cfg.Reset()
+		}
+		r.Statements = append(r.Statements, Statement{Command: &ResetConf{}})
+		return nil
+	}
+
+	// Check for USE statement
+	if matches := usePattern.FindStringSubmatch(trimmed); matches != nil {
+		dbName := strings.TrimSpace(matches[1])
+		r.CurrentSchema = dbName
 
-		// Pass through unchanged
-		result.Statements = append(result.Statements, trimmed)
+		if opts.DatabaseMap != nil {
+			// Database mapping mode: the engine checks that the database
+			// is attached, as CREATE DATABASE may attach it at run time
+			r.Statements = append(r.Statements, Statement{Command: &UseDatabase{Name: dbName}})
+		} else if strings.EqualFold(dbName, "default") {
+			// Legacy mode: Hive's default database is DuckDB's main schema
+			r.add("SET search_path = 'main'")
+		} else {
+			// Legacy mode: create schema and set search_path
+			r.add("CREATE SCHEMA IF NOT EXISTS " + dbName)
+			r.add("SET search_path = '" + dbName + "'")
+		}
+		return nil
 	}
 
-	return result, nil
+	// Check for CREATE TABLE. Statements that don't parse as Hive DDL are
+	// most likely DuckDB DDL and are passed through.
+	if createTablePattern.MatchString(trimmed) {
+		if ct, err := parseCreateTable(trimmed); err == nil {
+			cmd := &CreateTable{Name: ct.name, IfNotExists: ct.ifNotExists, Table: ct.table}
+			if opts.WarehouseTables && ct.table.Type == catalog.ManagedTable && !ct.temporary &&
+				ct.table.Storage.Format == "PARQUET" {
+				ct.table.WarehouseFiles = true
+			}
+			// File-backed tables are created by the engine as views
+			// over their location, and temporary tables apart from
+			// the permanent ones.
+			cmd.Temporary = ct.temporary
+			if ct.table.FileBacked() || ct.temporary {
+				cmd.Query = ct.asSelect
+				r.Statements = append(r.Statements, Statement{Command: cmd})
+				return nil
+			}
+			if db, name, ok := strings.Cut(ct.name, "."); ok && opts.DatabaseMap == nil && strings.EqualFold(db, "default") {
+				// Legacy mode: Hive's default database is DuckDB's main schema
+				ct.name = "main." + name
+			}
+			ddl, err := duckDBCreateTable(ct)
+			if err != nil {
+				return err
+			}
+			r.Statements = append(r.Statements, Statement{SQL: ddl, Command: cmd})
+			return nil
+		}
+	}
+
+	// Check for DROP TABLE, falling back to DuckDB's own syntax
+	if dropTablePattern.MatchString(trimmed) {
+		if cmd, err := parseDropTable(trimmed); err == nil {
+			r.Statements = append(r.Statements, Statement{Command: cmd})
+			return nil
+		}
+	}
+
+	// Check for emulated Hive statements
+	if cmd, err := parseCommand(trimmed); err != nil {
+		return err
+	} else if cmd != nil {
+		r.Statements = append(r.Statements, Statement{Command: cmd})
+		return nil
+	}
+
+	// Pass through unchanged
+	r.add(trimmed)
+	return nil
+}
+
+// parseCommand parses stmt if it is an emulated Hive statement. It returns a
+// nil command for any other statement.
+func parseCommand(stmt string) (Command, error) {
+	for _, p := range commandParsers {
+		if p.pattern.MatchString(stmt) {
+			cmd, err := p.parse(stmt)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", truncateStatement(stmt, 40), err)
+			}
+			return cmd, nil
+		}
+	}
+	return nil, nil
+}
+
+// add appends a plain SQL statement.
+func (r *RewriteResult) add(sql string) {
+	r.Statements = append(r.Statements, Statement{SQL: sql})
+}
+
+// UseTarget returns the database of a USE statement, and false for other
+// statements.
+func UseTarget(stmt string) (string, bool) {
+	if m := usePattern.FindStringSubmatch(strings.TrimSpace(stmt)); m != nil {
+		return m[1], true
+	}
+	return "", false
 }
 
 // IsHiveStatement returns true if the statement is a Hive-specific statement
 // that needs special handling.
 func IsHiveStatement(stmt string) bool {
 	trimmed := strings.TrimSpace(stmt)
-	return setPattern.MatchString(trimmed) || usePattern.MatchString(trimmed)
+	if setPattern.MatchString(trimmed) || showConfPattern.MatchString(trimmed) ||
+		listConfPattern.MatchString(trimmed) || resetPattern.MatchString(trimmed) ||
+		usePattern.MatchString(trimmed) {
+		return true
+	}
+	for _, p := range commandParsers {
+		if p.pattern.MatchString(trimmed) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,241 @@
+package preprocess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShowDatabases is SHOW DATABASES|SCHEMAS [LIKE 'pattern'].
+type ShowDatabases struct {
+	Pattern string
+}
+
+func (c *ShowDatabases) String() string {
+	return "SHOW DATABASES" + likeClause(c.Pattern)
+}
+
+// ShowTables is SHOW TABLES|VIEWS|MATERIALIZED VIEWS [IN db] [LIKE 'pattern'].
+type ShowTables struct {
+	Database     string
+	Pattern      string
+	Views        bool // SHOW VIEWS
+	Materialized bool // SHOW MATERIALIZED VIEWS
+}
+
+func (c *ShowTables) String() string {
+	s := "SHOW TABLES"
+	if c.Views {
+		s = "SHOW VIEWS"
+	} else if c.Materialized {
+		s = "SHOW MATERIALIZED VIEWS"
+	}
+	if c.Database != "" {
+		s += " IN " + c.Database
+	}
+	return s + likeClause(c.Pattern)
+}
+
+// ShowColumns is SHOW COLUMNS IN t [IN db] [LIKE 'pattern'].
+type ShowColumns struct {
+	Table   string
+	Pattern string
+}
+
+func (c *ShowColumns) String() string {
+	return "SHOW COLUMNS IN " + c.Table + likeClause(c.Pattern)
+}
+
+// ShowFunctions is SHOW FUNCTIONS [LIKE 'pattern'].
+type ShowFunctions struct {
+	Pattern string
+}
+
+func (c *ShowFunctions) String() string {
+	return "SHOW FUNCTIONS" + likeClause(c.Pattern)
+}
+
+// DescribeFunction is DESCRIBE FUNCTION [EXTENDED] f.
+type DescribeFunction struct {
+	Name     string
+	Extended bool
+}
+
+func (c *DescribeFunction) String() string {
+	if c.Extended {
+		return "DESCRIBE FUNCTION EXTENDED " + c.Name
+	}
+	return "DESCRIBE FUNCTION " + c.Name
+}
+
+// DescribeDatabase is DESCRIBE DATABASE|SCHEMA [EXTENDED] db.
+type DescribeDatabase struct {
+	Name     string
+	Extended bool
+}
+
+func (c *DescribeDatabase) String() string {
+	if c.Extended {
+		return "DESCRIBE DATABASE EXTENDED " + c.Name
+	}
+	return "DESCRIBE DATABASE " + c.Name
+}
+
+func likeClause(pattern string) string {
+	if pattern == "" {
+		return ""
+	}
+	return " LIKE '" + pattern + "'"
+}
+
+// parseLike parses an optional trailing [LIKE] 'pattern' and checks that
+// nothing follows it.
+func parseLike(ts *tokenStream, what string) (string, error) {
+	var pattern string
+	if ts.accept("LIKE") || ts.peek().kind == tokString {
+		var err error
+		if pattern, err = ts.str(); err != nil {
+			return "", err
+		}
+	}
+	if !ts.done() {
+		return "", fmt.Errorf("unexpected %q in %s", ts.rest(), what)
+	}
+	return pattern, nil
+}
+
+// parseShowDatabases parses SHOW DATABASES|SCHEMAS [LIKE 'pattern'].
+func parseShowDatabases(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if !ts.accept("SHOW", "DATABASES") && !ts.accept("SHOW", "SCHEMAS") {
+		return nil, fmt.Errorf("expected SHOW DATABASES")
+	}
+	c := &ShowDatabases{}
+	if c.Pattern, err = parseLike(ts, "SHOW DATABASES"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseShowTables parses SHOW TABLES|VIEWS|MATERIALIZED VIEWS [IN|FROM db]
+// [[LIKE] 'pattern'].
+func parseShowTables(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	c := &ShowTables{}
+	if ts.accept("SHOW", "VIEWS") {
+		c.Views = true
+	} else if ts.accept("SHOW", "MATERIALIZED", "VIEWS") {
+		c.Materialized = true
+	} else if err := ts.expect("SHOW", "TABLES"); err != nil {
+		return nil, err
+	}
+	if ts.accept("IN") || ts.accept("FROM") {
+		if c.Database, err = ts.ident(); err != nil {
+			return nil, err
+		}
+	}
+	if c.Pattern, err = parseLike(ts, "SHOW TABLES"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseShowColumns parses SHOW COLUMNS IN|FROM t [IN|FROM db] [LIKE 'pattern'].
+func parseShowColumns(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("SHOW", "COLUMNS"); err != nil {
+		return nil, err
+	}
+	if !ts.accept("IN") && !ts.accept("FROM") {
+		return nil, fmt.Errorf("expected IN or FROM near %q", ts.rest())
+	}
+	c := &ShowColumns{}
+	if c.Table, err = ts.tableName(); err != nil {
+		return nil, err
+	}
+	if ts.accept("IN") || ts.accept("FROM") {
+		db, err := ts.ident()
+		if err != nil {
+			return nil, err
+		}
+		c.Table = db + "." + c.Table
+	}
+	if c.Pattern, err = parseLike(ts, "SHOW COLUMNS"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseShowFunctions parses SHOW FUNCTIONS [[LIKE] 'pattern'].
+func parseShowFunctions(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := ts.expect("SHOW", "FUNCTIONS"); err != nil {
+		return nil, err
+	}
+	c := &ShowFunctions{}
+	if c.Pattern, err = parseLike(ts, "SHOW FUNCTIONS"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseDescribeFunction parses DESCRIBE FUNCTION [EXTENDED] f. Operators
+// such as DESCRIBE FUNCTION + are accepted as function names.
+func parseDescribeFunction(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if !ts.accept("DESCRIBE") && !ts.accept("DESC") {
+		return nil, fmt.Errorf("expected DESCRIBE")
+	}
+	if err := ts.expect("FUNCTION"); err != nil {
+		return nil, err
+	}
+	c := &DescribeFunction{Extended: ts.accept("EXTENDED")}
+	if ts.done() {
+		return nil, fmt.Errorf("expected function name")
+	}
+	c.Name = strings.TrimSpace(ts.rest())
+	if t := ts.peek(); t.kind == tokWord || t.kind == tokString {
+		c.Name = t.text
+		ts.next()
+		if !ts.done() {
+			return nil, fmt.Errorf("unexpected %q in DESCRIBE FUNCTION", ts.rest())
+		}
+	}
+	return c, nil
+}
+
+// parseDescribeDatabase parses DESCRIBE DATABASE|SCHEMA [EXTENDED] db.
+func parseDescribeDatabase(stmt string) (Command, error) {
+	ts, err := newTokenStream(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if !ts.accept("DESCRIBE") && !ts.accept("DESC") {
+		return nil, fmt.Errorf("expected DESCRIBE")
+	}
+	if !ts.accept("DATABASE") && !ts.accept("SCHEMA") {
+		return nil, fmt.Errorf("expected DATABASE near %q", ts.rest())
+	}
+	c := &DescribeDatabase{Extended: ts.accept("EXTENDED")}
+	if c.Name, err = ts.ident(); err != nil {
+		return nil, err
+	}
+	if !ts.done() {
+		return nil, fmt.Errorf("unexpected %q in DESCRIBE DATABASE", ts.rest())
+	}
+	return c, nil
+}
@@ -0,0 +1,128 @@
+package preprocess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position is a place in a script, with 1-based line and column. The
+// column counts characters.
+type Position struct {
+	File string
+	Line int
+	Col  int
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String renders the position as file:line:col, or line:col for a script
+// given with -e.
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// script is the text of a SQL file or -e string.
+type script struct {
+	file  string
+	text  string
+	lines []int // offsets of the starts of the lines
+}
+
+func newScript(file, text string) *script {
+	s := &script{file: file, text: text, lines: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			s.lines = append(s.lines, i+1)
+		}
+	}
+	return s
+}
+
+// position returns the position of the byte at offset.
+func (s *script) position(offset int) Position {
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset }) - 1
+	return Position{File: s.file, Line: line + 1, Col: utf8.RuneCountInString(s.text[s.lines[line]:offset]) + 1}
+}
+
+// Source is a statement of a script that remembers where its text comes
+// from, through comment removal and variable substitution, to report
+// positions in the script. A Source built from a bare string has no
+// positions.
+type Source struct {
+	SQL     string
+	script  *script
+	offsets []int // offsets[i] is the offset in the script of SQL[i]
+}
+
+// Position returns the position in the script of the byte at offset i of
+// the statement, or an invalid position if it is not known.
+func (s Source) Position(i int) Position {
+	if s.script == nil || i < 0 || i >= len(s.offsets) {
+		return Position{}
+	}
+	return s.script.position(s.offsets[i])
+}
+
+// Start returns the position of the first character of the statement.
+func (s Source) Start() Position {
+	return s.Position(0)
+}
+
+// End returns the position of the last character of the statement.
+func (s Source) End() Position {
+	return s.Position(len(s.SQL) - 1)
+}
+
+// trimSpace trims the statement as strings.TrimSpace does.
+func (s Source) trimSpace() Source {
+	start := len(s.SQL) - len(strings.TrimLeft(s.SQL, " \t\r\n\v\f"))
+	end := len(strings.TrimRight(s.SQL, " \t\r\n\v\f"))
+	if end < start {
+		end = start
+	}
+	return s.slice(start, end)
+}
+
+// slice returns the part of the statement from byte start to end.
+func (s Source) slice(start, end int) Source {
+	out := Source{SQL: s.SQL[start:end], script: s.script}
+	if s.offsets != nil {
+		out.offsets = s.offsets[start:end]
+	}
+	return out
+}
+
+// sourceBuilder builds a Source byte by byte from a script.
+type sourceBuilder struct {
+	b       strings.Builder
+	offsets []int
+}
+
+// write appends text that comes from the script at offset; every byte of
+// text maps to offset if same is set, else to the bytes from offset on.
+func (sb *sourceBuilder) write(text string, offset int, same bool) {
+	sb.b.WriteString(text)
+	for i := range text {
+		if same {
+			sb.offsets = append(sb.offsets, offset)
+		} else {
+			sb.offsets = append(sb.offsets, offset+i)
+		}
+	}
+}
+
+func (sb *sourceBuilder) source(sc *script) Source {
+	src := Source{SQL: sb.b.String(), script: sc}
+	if sc != nil {
+		src.offsets = sb.offsets
+	}
+	return src
+}
@@ -2,34 +2,57 @@ package preprocess
 
 import (
 	"fmt"
-	"strings"
 )
 
+// SplitStatements splits a script into statements on the semicolons outside
+// quotes, dropping comments.
 func SplitStatements(sql string) ([]string, error) {
+	srcs, err := SplitScript("", sql)
+	if err != nil {
+		return nil, err
+	}
+	stmts := make([]string, len(srcs))
+	for i, src := range srcs {
+		stmts[i] = src.SQL
+	}
+	return stmts, nil
+}
+
+// SplitScript splits a script as SplitStatements does, keeping where each
+// statement is in the file for error messages.
+func SplitScript(file, sql string) ([]Source, error) {
 	var (
-		stmts []string
-		buf   strings.Builder
+		stmts []Source
+		buf   sourceBuilder
 
 		inSQuote bool
 		inDQuote bool
 		inLineC  bool
 		inBlockC bool
+		opened   int // offset of the open quote or block comment
 	)
+	sc := newScript(file, sql)
+	flush := func() {
+		stmt := buf.source(sc).trimSpace()
+		buf = sourceBuilder{}
+		if stmt.SQL != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
 
-	r := []rune(sql)
-	for i := 0; i < len(r); i++ {
-		ch := r[i]
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
 
 		// Comment handling
 		if inLineC {
 			if ch == '\n' {
 				inLineC = false
-				buf.WriteRune(ch)
+				buf.write("\n", i, false)
 			}
 			continue
 		}
 		if inBlockC {
-			if ch == '*' && i+1 < len(r) && r[i+1] == '/' {
+			if ch == '*' && i+1 < len(sql) && sql[i+1] == '/' {
 				inBlockC = false
 				i++
 			}
@@ -38,13 +61,13 @@ func SplitStatements(sql string) ([]string, error) {
 
 		// Start comments (only when not in quotes)
 		if !inSQuote && !inDQuote {
-			if ch == '-' && i+1 < len(r) && r[i+1] == '-' {
+			if ch == '-' && i+1 < len(sql) && sql[i+1] == '-' {
 				inLineC = true
 				i++
 				continue
 			}
-			if ch == '/' && i+1 < len(r) && r[i+1] == '*' {
-				inBlockC = true
+			if ch == '/' && i+1 < len(sql) && sql[i+1] == '*' {
+				inBlockC, opened = true, i
 				i++
 				continue
 			}
@@ -53,42 +76,38 @@ func SplitStatements(sql string) ([]string, error) {
 		// Quote toggles (handle escaped '' inside single quotes)
 		if !inDQuote && ch == '\'' {
 			// If already in single quote and next is also ', treat as escaped quote
-			if inSQuote && i+1 < len(r) && r[i+1] == '\'' {
-				buf.WriteRune(ch)
-				buf.WriteRune(r[i+1])
+			if inSQuote && i+1 < len(sql) && sql[i+1] == '\'' {
+				buf.write("''", i, false)
 				i++
 				continue
 			}
-			inSQuote = !inSQuote
-			buf.WriteRune(ch)
+			inSQuote, opened = !inSQuote, i
+			buf.write("'", i, false)
 			continue
 		}
 		if !inSQuote && ch == '"' {
-			inDQuote = !inDQuote
-			buf.WriteRune(ch)
+			inDQuote, opened = !inDQuote, i
+			buf.write(`"`, i, false)
 			continue
 		}
 
 		// Statement split
 		if ch == ';' && !inSQuote && !inDQuote {
-			stmt := strings.TrimSpace(buf.String())
-			buf.Reset()
-			if stmt != "" {
-				stmts = append(stmts, stmt)
-			}
+			flush()
 			continue
 		}
 
-		buf.WriteRune(ch)
+		buf.write(sql[i:i+1], i, false)
 	}
 
 	if inSQuote || inDQuote || inBlockC {
-		return nil, fmt.Errorf("unterminated quote or comment in SQL input")
+		pos := sc.position(opened)
+		if file == "" {
+			return nil, fmt.Errorf("unterminated quote or comment in SQL input at %s", pos)
+		}
+		return nil, fmt.Errorf("%s: unterminated quote or comment in SQL input", pos)
 	}
 
-	last := strings.TrimSpace(buf.String())
-	if last != "" {
-		stmts = append(stmts, last)
-	}
+	flush()
 	return stmts, nil
 }
@@ -8,14 +8,29 @@ import (
 	"github.com/danieljhkim/hive-duck/internal/config"
 )
 
-var re = regexp.MustCompile(`\$\{(hiveconf|hivevar|env):([A-Za-z0-9_.\-]+)\}`)
+var re = regexp.MustCompile(`\$\{(hiveconf|hivevar|system|env):([A-Za-z0-9_.\-]+)\}`)
 
 func Substitute(sql string, cfg *config.Config) (string, error) {
+	src, err := SubstituteSource(Source{SQL: sql}, cfg)
+	return src.SQL, err
+}
+
+// SubstituteSource substitutes the variables of a statement as Substitute
+// does, keeping track of where its text comes from: the value of a variable
+// is located at the variable.
+func SubstituteSource(src Source, cfg *config.Config) (Source, error) {
 	var missing []string
+	var firstMissing Position
 
-	out := re.ReplaceAllStringFunc(sql, func(m string) string {
-		sub := re.FindStringSubmatch(m)
-		kind, key := sub[1], sub[2]
+	var b sourceBuilder
+	last := 0
+	write := func(start, end int) {
+		for i := start; i < end; i++ {
+			b.write(src.SQL[i:i+1], offsetOf(src, i), false)
+		}
+	}
+	for _, m := range re.FindAllStringSubmatchIndex(src.SQL, -1) {
+		kind, key := src.SQL[m[2]:m[3]], src.SQL[m[4]:m[5]]
 
 		var val string
 		var ok bool
@@ -24,20 +39,41 @@ func Substitute(sql string, cfg *config.Config) (string, error) {
 			val, ok = cfg.HiveConf[key]
 		case "hivevar":
 			val, ok = cfg.HiveVar[key]
+		case "system":
+			val, ok = cfg.System[key]
 		case "env":
 			val, ok = cfg.Env[key]
 		}
 
 		if !ok {
-			missing = append(missing, m)
-			return m
+			if len(missing) == 0 {
+				firstMissing = src.Position(m[0])
+			}
+			missing = append(missing, src.SQL[m[0]:m[1]])
+			continue
 		}
 		// Conservative: substitute raw; callers should quote in SQL when needed.
-		return val
-	})
+		write(last, m[0])
+		b.write(val, offsetOf(src, m[0]), true)
+		last = m[1]
+	}
+	write(last, len(src.SQL))
 
 	if cfg.StrictVars && len(missing) > 0 {
-		return "", fmt.Errorf("missing variables: %s", strings.Join(missing, ", "))
+		err := fmt.Errorf("missing variables: %s", strings.Join(missing, ", "))
+		if firstMissing.IsValid() {
+			err = fmt.Errorf("%s: %w", firstMissing, err)
+		}
+		return Source{}, err
+	}
+	return b.source(src.script), nil
+}
+
+// offsetOf returns the offset in the script of byte i of a statement, or i
+// if the statement has no positions.
+func offsetOf(src Source, i int) int {
+	if i < len(src.offsets) {
+		return src.offsets[i]
 	}
-	return out, nil
+	return i
 }
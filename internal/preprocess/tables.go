@@ -0,0 +1,191 @@
+package preprocess
+
+import "strings"
+
+// tableKeywords are the keywords followed by a table name.
+var tableKeywords = []string{"FROM", "JOIN", "INTO", "UPDATE", "TABLE", "USING"}
+
+// fromListEnd are the keywords that end the table list of a FROM clause.
+var fromListEnd = []string{"WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "QUALIFY", "WINDOW",
+	"UNION", "EXCEPT", "INTERSECT", "SELECT", "SET", "VALUES"}
+
+// ReplaceTableNames rewrites the table names of a statement: each name, of
+// one or two parts, after FROM, JOIN, INTO, UPDATE, TABLE or USING, or in
+// the comma-separated table list of a FROM clause, is passed to replace,
+// and replaced by the returned SQL if it returns true. The database is
+// empty for unqualified names. Table functions such as read_csv(...) are
+// left alone, as are the FROM of function arguments such as
+// extract(year FROM d), the names of the statement's CTEs and a statement
+// that does not tokenize.
+func ReplaceTableNames(stmt string, replace func(db, name string) (string, bool)) string {
+	toks, err := tokenize(stmt)
+	if err != nil {
+		return stmt
+	}
+	ctes := cteNames(toks)
+	var (
+		b         strings.Builder
+		last      int
+		depth     int
+		fromLists []int  // paren depths of the open FROM clauses
+		calls     []bool // whether each open paren holds function arguments
+		expect    bool   // the next token starts a table name
+	)
+	inFromList := func() bool { return len(fromLists) > 0 && fromLists[len(fromLists)-1] == depth }
+	inCall := func() bool { return len(calls) > 0 && calls[len(calls)-1] }
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.is("("):
+			depth++
+			calls = append(calls, i > 0 && isCall(toks[i-1], toks[i+1:]))
+			expect = false
+			continue
+		case t.is(")"):
+			depth--
+			if len(calls) > 0 {
+				calls = calls[:len(calls)-1]
+			}
+			for len(fromLists) > 0 && fromLists[len(fromLists)-1] > depth {
+				fromLists = fromLists[:len(fromLists)-1]
+			}
+			continue
+		case t.is(","):
+			expect = inFromList()
+			continue
+		case t.kind != tokWord:
+			expect = false
+			continue
+		}
+		if inFromList() && isAnyWord(t, fromListEnd) {
+			fromLists = fromLists[:len(fromLists)-1]
+		}
+		if isAnyWord(t, tableKeywords) && !inCall() {
+			if t.is("FROM") {
+				fromLists = append(fromLists, depth)
+			}
+			expect = true
+			continue
+		}
+		if !expect {
+			continue
+		}
+		expect = false
+
+		parts := nameParts(stmt, t)
+		j := i
+		for j+2 < len(toks) && toks[j+1].is(".") && toks[j+2].kind == tokWord {
+			parts = append(parts, nameParts(stmt, toks[j+2])...)
+			j += 2
+		}
+		if len(parts) > 2 || (j+1 < len(toks) && toks[j+1].is("(")) {
+			i = j
+			continue
+		}
+		db, name := "", parts[len(parts)-1]
+		if len(parts) == 2 {
+			db = parts[0]
+		}
+		if db == "" && ctes[strings.ToLower(name)] {
+			i = j
+			continue
+		}
+		if sql, ok := replace(strings.ToLower(db), strings.ToLower(name)); ok {
+			b.WriteString(stmt[last:t.start])
+			b.WriteString(sql)
+			last = toks[j].end
+		}
+		i = j
+	}
+	if last == 0 {
+		return stmt
+	}
+	b.WriteString(stmt[last:])
+	return b.String()
+}
+
+// isCall reports whether a paren after prev opens the arguments of a
+// function call, such as extract(year FROM d), rather than a subquery or a
+// parenthesized join.
+func isCall(prev token, rest []token) bool {
+	if prev.kind != tokWord || isAnyWord(prev, tableKeywords) {
+		return false
+	}
+	return len(rest) == 0 || !(rest[0].is("SELECT") || rest[0].is("WITH"))
+}
+
+// cteNames returns the lower-cased names of the CTEs a statement defines in
+// its WITH clauses: WITH [RECURSIVE] name [(columns)] AS [[NOT]
+// MATERIALIZED] (query), ...
+func cteNames(toks []token) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < len(toks); i++ {
+		if !toks[i].is("WITH") {
+			continue
+		}
+		j := i + 1
+		if j < len(toks) && toks[j].is("RECURSIVE") {
+			j++
+		}
+		for j < len(toks) && toks[j].kind == tokWord {
+			name := strings.ToLower(toks[j].text)
+			j++
+			if j < len(toks) && toks[j].is("(") {
+				j = skipGroup(toks, j)
+			}
+			if j >= len(toks) || !toks[j].is("AS") {
+				break
+			}
+			j++
+			if j < len(toks) && toks[j].is("NOT") {
+				j++
+			}
+			if j < len(toks) && toks[j].is("MATERIALIZED") {
+				j++
+			}
+			if j >= len(toks) || !toks[j].is("(") {
+				break
+			}
+			names[name] = true
+			j = skipGroup(toks, j)
+			if j >= len(toks) || !toks[j].is(",") {
+				break
+			}
+			j++
+		}
+	}
+	return names
+}
+
+// skipGroup returns the index after the paren group opened at toks[i].
+func skipGroup(toks []token, i int) int {
+	depth := 0
+	for ; i < len(toks); i++ {
+		if toks[i].is("(") {
+			depth++
+		} else if toks[i].is(")") {
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+func isAnyWord(t token, words []string) bool {
+	for _, w := range words {
+		if t.is(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameParts splits a word token into the parts of a dotted name; the
+// lexer keeps unquoted names such as db.tbl in one token.
+func nameParts(stmt string, t token) []string {
+	if stmt[t.start] == '`' {
+		return []string{t.text}
+	}
+	return strings.Split(t.text, ".")
+}
@@ -7,9 +7,10 @@ import (
 
 // UnsupportedResult represents a detected unsupported Hive statement.
 type UnsupportedResult struct {
-	Statement string // Original statement (truncated for display)
-	Keyword   string // Detected unsupported keyword/pattern
-	Reason    string // Why it's unsupported
+	Statement string   // Original statement (truncated for display)
+	Keyword   string   // Detected unsupported keyword/pattern
+	Reason    string   // Why it's unsupported
+	Position  Position // Where the statement starts in the script
 }
 
 // Unsupported statement patterns with descriptions
@@ -18,23 +19,6 @@ var unsupportedPatterns = []struct {
 	keyword string
 	reason  string
 }{
-	// Data Loading
-	{
-		regexp.MustCompile(`(?i)^\s*LOAD\s+DATA`),
-		"LOAD DATA",
-		"Use DuckDB's read_csv/read_parquet/COPY instead",
-	},
-	{
-		regexp.MustCompile(`(?i)^\s*EXPORT\s+TABLE`),
-		"EXPORT TABLE",
-		"Use DuckDB's COPY TO instead",
-	},
-	{
-		regexp.MustCompile(`(?i)^\s*IMPORT\s+TABLE`),
-		"IMPORT TABLE",
-		"Use DuckDB's COPY FROM instead",
-	},
-
 	// UDF/Transform
 	{
 		regexp.MustCompile(`(?i)^\s*ADD\s+JAR`),
@@ -68,25 +52,15 @@ var unsupportedPatterns = []struct {
 	},
 
 	// Hive DDL
-	{
-		regexp.MustCompile(`(?i)^\s*MSCK\s+REPAIR`),
-		"MSCK REPAIR",
-		"Partition repair not needed; DuckDB doesn't use Hive metastore",
-	},
 	{
 		regexp.MustCompile(`(?i)^\s*ANALYZE\s+TABLE`),
 		"ANALYZE TABLE",
 		"Use DuckDB's ANALYZE instead",
 	},
 	{
-		regexp.MustCompile(`(?i)^\s*TRUNCATE\s+TABLE`),
-		"TRUNCATE TABLE",
-		"Use DELETE FROM table or DROP TABLE + CREATE TABLE",
-	},
-	{
-		regexp.MustCompile(`(?i)^\s*ALTER\s+TABLE\s+\S+\s+(ADD|DROP|RENAME)\s+PARTITION`),
-		"ALTER TABLE...PARTITION",
-		"Hive partition management not supported; use DuckDB partitioning",
+		regexp.MustCompile(`(?i)^\s*ALTER\s+TABLE\s+\S+\s+(PARTITION\s*\([^)]*\)\s+)?RENAME\s+(TO\s+)?PARTITION`),
+		"ALTER TABLE...RENAME PARTITION",
+		"Renaming partitions not supported; DROP and re-ADD the partition instead",
 	},
 	{
 		regexp.MustCompile(`(?i)^\s*ALTER\s+TABLE\s+\S+\s+RECOVER\s+PARTITIONS`),
@@ -121,28 +95,6 @@ var unsupportedPatterns = []struct {
 		"Use DuckDB's USING SAMPLE clause instead",
 	},
 
-	// Metastore operations
-	{
-		regexp.MustCompile(`(?i)^\s*SHOW\s+PARTITIONS`),
-		"SHOW PARTITIONS",
-		"Hive partitions not applicable; data is file-based",
-	},
-	{
-		regexp.MustCompile(`(?i)^\s*SHOW\s+TBLPROPERTIES`),
-		"SHOW TBLPROPERTIES",
-		"Table properties not stored in Hive metastore format",
-	},
-	{
-		regexp.MustCompile(`(?i)^\s*DESCRIBE\s+EXTENDED`),
-		"DESCRIBE EXTENDED",
-		"Use DESCRIBE or PRAGMA table_info instead",
-	},
-	{
-		regexp.MustCompile(`(?i)^\s*DESCRIBE\s+FORMATTED`),
-		"DESCRIBE FORMATTED",
-		"Use DESCRIBE or PRAGMA table_info instead",
-	},
-
 	// Storage format hints
 	{
 		regexp.MustCompile(`(?i)\bSTORED\s+AS\b`),
@@ -159,11 +111,6 @@ var unsupportedPatterns = []struct {
 		"SERDE",
 		"SerDe not supported; use DuckDB's native readers",
 	},
-	{
-		regexp.MustCompile(`(?i)\bLOCATION\s+'`),
-		"LOCATION",
-		"External table location; use CREATE TABLE AS or views with read_* functions",
-	},
 
 	// Locks and transactions (Hive-specific)
 	{
@@ -180,21 +127,23 @@ var unsupportedPatterns = []struct {
 
 // DetectUnsupported scans statements for unsupported Hive-specific constructs.
 // Returns a list of all detected issues.
-func DetectUnsupported(stmts []string) []UnsupportedResult {
+func DetectUnsupported(stmts []Source) []UnsupportedResult {
 	var results []UnsupportedResult
 
 	for _, stmt := range stmts {
-		trimmed := strings.TrimSpace(stmt)
+		src := stmt.trimSpace()
+		trimmed := src.SQL
 		if trimmed == "" {
 			continue
 		}
 
 		for _, p := range unsupportedPatterns {
-			if p.pattern.MatchString(trimmed) {
+			if p.pattern.MatchString(trimmed) && !excepted(p.keyword, trimmed) {
 				results = append(results, UnsupportedResult{
 					Statement: truncateStatement(trimmed, 80),
 					Keyword:   p.keyword,
 					Reason:    p.reason,
+					Position:  src.Start(),
 				})
 				// Don't break - a statement might match multiple patterns
 			}
@@ -205,14 +154,14 @@ func DetectUnsupported(stmts []string) []UnsupportedResult {
 }
 
 // HasUnsupported returns true if any unsupported statements are detected.
-func HasUnsupported(stmts []string) bool {
+func HasUnsupported(stmts []Source) bool {
 	for _, stmt := range stmts {
-		trimmed := strings.TrimSpace(stmt)
+		trimmed := strings.TrimSpace(stmt.SQL)
 		if trimmed == "" {
 			continue
 		}
 		for _, p := range unsupportedPatterns {
-			if p.pattern.MatchString(trimmed) {
+			if p.pattern.MatchString(trimmed) && !excepted(p.keyword, trimmed) {
 				return true
 			}
 		}
@@ -220,6 +169,24 @@ func HasUnsupported(stmts []string) bool {
 	return false
 }
 
+// unsupportedExceptions lists statements that Rewrite handles even though
+// they contain an otherwise unsupported keyword.
+var unsupportedExceptions = map[string][]*regexp.Regexp{
+	"STORED AS":  {createTablePattern, createMaterializedViewPattern},
+	"ROW FORMAT": {createTablePattern, createMaterializedViewPattern},
+	"SERDE":      {createTablePattern, createMaterializedViewPattern, alterTablePattern},
+}
+
+// excepted reports whether stmt is exempt from the pattern named keyword.
+func excepted(keyword, stmt string) bool {
+	for _, except := range unsupportedExceptions[keyword] {
+		if except.MatchString(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
 // truncateStatement shortens a statement for display.
 func truncateStatement(s string, maxLen int) string {
 	// Normalize whitespace
@@ -0,0 +1,3 @@
+-- What the atomic runs left
+SELECT count(*) AS orders FROM sales.orders;
+SELECT count(*) AS staff FROM hr.staff;
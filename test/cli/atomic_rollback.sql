@@ -0,0 +1,3 @@
+-- An atomic run that writes, then fails
+INSERT INTO sales.orders VALUES (2);
+SELECT * FROM sales.missing;
@@ -0,0 +1,4 @@
+-- Tables for the atomic runs
+CREATE TABLE sales.orders (id INT);
+INSERT INTO sales.orders VALUES (1);
+CREATE TABLE hr.staff (id INT);
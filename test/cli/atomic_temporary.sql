@@ -0,0 +1,7 @@
+-- Hive temporary tables are rejected before an atomic run starts
+SELECT 'not run' AS status;
+
+CREATE TEMPORARY TABLE staged AS SELECT 1 AS id;
+
+CREATE TABLE ids (id INT);
+INSERT INTO ids SELECT * FROM staged;
@@ -0,0 +1,3 @@
+-- An atomic run that writes two databases
+INSERT INTO sales.orders VALUES (3);
+INSERT INTO hr.staff VALUES (1);
@@ -0,0 +1,11 @@
+-- Creates a table whose metadata a later run reads back
+USE sales;
+CREATE TABLE orders (
+  id INT COMMENT 'order id',
+  amount DECIMAL(10,2) COMMENT 'total'
+)
+COMMENT 'customer orders'
+PARTITIONED BY (dt STRING COMMENT 'order date')
+TBLPROPERTIES ('owner.team'='billing');
+INSERT INTO orders PARTITION (dt='2024-01-01') VALUES (1, 9.50);
+ALTER TABLE orders ADD PARTITION (dt='2024-01-02');
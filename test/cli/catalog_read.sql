@@ -0,0 +1,6 @@
+-- Reads back the metadata of the table catalog_create.sql created
+USE sales;
+SHOW TBLPROPERTIES orders('owner.team');
+SHOW TBLPROPERTIES orders('comment');
+SHOW PARTITIONS orders;
+DESCRIBE orders;
@@ -0,0 +1,3 @@
+-- Invalid column: SemanticException 10004, exit status 20
+SELECT id,
+       amt FROM (SELECT 1 AS id) t;
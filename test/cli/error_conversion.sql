@@ -0,0 +1,2 @@
+-- Failed conversion: Execution Error, return code 2
+SELECT CAST('abc' AS INT);
@@ -0,0 +1,2 @@
+-- Syntax error: ParseException, exit status 64
+SELECT * FORM t;
@@ -0,0 +1,2 @@
+-- Table not found: SemanticException 10001, exit status 17
+SELECT * FROM orders;
@@ -0,0 +1,6 @@
+-- Failed statements are skipped when errors are ignored
+SELECT 1 AS a;
+SELECT * FROM missing;
+SELECT 2 AS b;
+SELECT CAST('x' AS INT);
+SELECT 3 AS c;
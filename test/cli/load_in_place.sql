@@ -0,0 +1,12 @@
+-- LOAD DATA of a file already in the table location
+CREATE EXTERNAL TABLE users (id INT, name STRING)
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+LOCATION '${hivevar:dir}/users';
+
+-- The file stays as it is
+LOAD DATA LOCAL INPATH '${hivevar:dir}/users/part-0.csv' INTO TABLE users;
+
+SELECT count(*) AS n FROM users;
+
+-- Emptying the location would delete the file
+LOAD DATA LOCAL INPATH '${hivevar:dir}/users/part-0.csv' OVERWRITE INTO TABLE users;
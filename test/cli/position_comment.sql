@@ -0,0 +1,5 @@
+-- Errors are placed in the script past comments
+SELECT 1 AS a; -- a comment after it
+/* a block
+   comment */ SELECT amt
+FROM (SELECT 1 AS a) t;
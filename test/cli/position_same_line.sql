@@ -0,0 +1,2 @@
+-- Errors are placed within a line of several statements
+SELECT 1 AS a; SELECT 2 AS b; SELECT amt FROM (SELECT 1 AS a) t;
@@ -0,0 +1,3 @@
+-- The place of an error is in the script as written, before substitution
+SET hivevar:col=amount;
+SELECT ${hivevar:col}, amt FROM (SELECT 1 AS amount) t;
@@ -0,0 +1,3 @@
+-- SET -v lists every value, the environment included, and SET the rest
+SET hivevar:greeting=hello;
+SET -v;
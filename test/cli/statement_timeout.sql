@@ -0,0 +1,9 @@
+-- A statement running in a transaction is stopped by --statement-timeout
+CREATE TABLE counts (n BIGINT);
+
+MERGE INTO counts t
+USING (SELECT count(*) AS n FROM range(100000000000) r WHERE r.range % 7 = 10) s
+ON t.n = s.n
+WHEN NOT MATCHED THEN INSERT VALUES (s.n);
+
+SELECT 'not run' AS status;
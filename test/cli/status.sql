@@ -0,0 +1,5 @@
+-- Each statement is reported on stderr, but SET
+SET hivevar:n=2;
+CREATE TABLE t (id INT);
+INSERT INTO t VALUES (1), (${hivevar:n});
+SELECT id FROM t ORDER BY id;
@@ -0,0 +1,465 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danieljhkim/hive-duck/internal/catalog"
+)
+
+var (
+	buildOnce sync.Once
+	binDir    string
+	buildErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if binDir != "" {
+		os.RemoveAll(binDir)
+	}
+	os.Exit(code)
+}
+
+// buildHiveDuck builds hive-duck once for the tests that run it directly,
+// so that they see its exit status, which go run does not pass on.
+func buildHiveDuck(t *testing.T) string {
+	t.Helper()
+	buildOnce.Do(func() {
+		if binDir, buildErr = os.MkdirTemp("", "hive-duck-test"); buildErr != nil {
+			return
+		}
+		out, err := exec.Command("go", "build", "-o", filepath.Join(binDir, "hive-duck"), "../cmd/hive-duck").CombinedOutput()
+		if err != nil {
+			buildErr = fmt.Errorf("%v\n%s", err, out)
+		}
+	})
+	if buildErr != nil {
+		t.Fatalf("Failed to build hive-duck: %v", buildErr)
+	}
+	return filepath.Join(binDir, "hive-duck")
+}
+
+// runHiveDuck runs hive-duck and returns its stdout, stderr and exit status.
+// A run that does not end within a minute is killed.
+func runHiveDuck(t *testing.T, bin string, args ...string) (string, string, int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	code := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("Failed to run hive-duck: %v", err)
+		}
+		code = exitErr.ExitCode()
+	}
+	return stdout.String(), stderr.String(), code
+}
+
+// TestImportDDL imports a DDL dump with statements that are created,
+// skipped and failed, and checks the report, the summary and the exit
+// status.
+func TestImportDDL(t *testing.T) {
+	bin := buildHiveDuck(t)
+	stdout, stderr, code := runHiveDuck(t, bin, "import-ddl", "import_ddl")
+
+	expectedBytes, err := os.ReadFile(filepath.Join("import_ddl", "expected.out"))
+	if err != nil {
+		t.Fatalf("Failed to read expected.out: %v", err)
+	}
+	expected := strings.TrimSpace(string(expectedBytes))
+	if actual := strings.TrimSpace(stdout); actual != expected {
+		t.Errorf("Output mismatch:\n--- Expected ---\n%s\n--- Actual ---\n%s\n--- Diff ---\n%s",
+			expected, actual, diffStrings(expected, actual))
+	}
+	if summary := "2 created, 2 skipped, 1 failed"; !strings.Contains(stderr, summary) {
+		t.Errorf("Stderr does not contain %q:\n%s", summary, stderr)
+	}
+	if code != 1 {
+		t.Errorf("Exit status %d, want 1\nStderr: %s", code, stderr)
+	}
+}
+
+// TestAtomicTemporaryTable checks that an atomic run with a Hive temporary
+// table fails before running anything.
+func TestAtomicTemporaryTable(t *testing.T) {
+	bin := buildHiveDuck(t)
+	stdout, stderr, code := runHiveDuck(t, bin, "--atomic", "-f", filepath.Join("cli", "atomic_temporary.sql"))
+
+	if stdout != "" {
+		t.Errorf("Statements ran:\n%s", stdout)
+	}
+	if msg := "cli/atomic_temporary.sql:4:1: CREATE TEMPORARY TABLE staged"; !strings.Contains(stderr, msg) {
+		t.Errorf("Stderr does not contain %q:\n%s", msg, stderr)
+	}
+	if code != 1 {
+		t.Errorf("Exit status %d, want 1\nStderr: %s", code, stderr)
+	}
+}
+
+// TestStatementTimeout checks that --statement-timeout stops a statement
+// running in a transaction, here the staging of a MERGE source, and that
+// the script stops with timeout(1)'s exit status.
+func TestStatementTimeout(t *testing.T) {
+	bin := buildHiveDuck(t)
+	stdout, stderr, code := runHiveDuck(t, bin, "--statement-timeout", "1s", "-f", filepath.Join("cli", "statement_timeout.sql"))
+
+	if strings.Contains(stdout, "not run") {
+		t.Errorf("The script went on after the timeout:\n%s", stdout)
+	}
+	if msg := "FAILED: Statement timed out after 1s: MERGE INTO counts t\n  at cli/statement_timeout.sql:4:1"; !strings.Contains(stderr, msg) {
+		t.Errorf("Stderr does not contain %q:\n%s", msg, stderr)
+	}
+	if code != 124 {
+		t.Errorf("Exit status %d, want 124\nStderr: %s", code, stderr)
+	}
+}
+
+// TestSetList checks that SET -v lists every value, with the environment,
+// rather than taking -v for a key.
+func TestSetList(t *testing.T) {
+	bin := buildHiveDuck(t)
+	stdout, stderr, code := runHiveDuck(t, bin, "-f", filepath.Join("cli", "set_list.sql"))
+
+	if code != 0 {
+		t.Fatalf("Exit status %d\nStderr: %s", code, stderr)
+	}
+	for _, line := range []string{"hivevar:greeting=hello", "env:PATH="} {
+		if !strings.Contains(stdout, "\n"+line) {
+			t.Errorf("Stdout does not list %q:\n%s", line, stdout)
+		}
+	}
+	if strings.Contains(stdout, "undefined") {
+		t.Errorf("SET -v was taken for a key:\n%s", stdout)
+	}
+}
+
+// TestLoadInPlace checks that LOAD DATA of a file already in the table
+// location leaves it as it is, and that OVERWRITE refuses to delete it.
+func TestLoadInPlace(t *testing.T) {
+	bin := buildHiveDuck(t)
+	dir := t.TempDir()
+	data := "1,ann\n2,bob\n"
+	file := filepath.Join(dir, "users", "part-0.csv")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, code := runHiveDuck(t, bin, "-f", filepath.Join("cli", "load_in_place.sql"), "--hivevar", "dir="+dir)
+
+	if actual, expected := strings.TrimSpace(stdout), "n\n2"; actual != expected {
+		t.Errorf("Output mismatch:\n--- Expected ---\n%s\n--- Actual ---\n%s", expected, actual)
+	}
+	if msg := "which is inside it"; !strings.Contains(stderr, msg) {
+		t.Errorf("Stderr does not contain %q:\n%s", msg, stderr)
+	}
+	if code == 0 {
+		t.Errorf("Exit status 0, want a failure\nStderr: %s", stderr)
+	}
+	if b, err := os.ReadFile(file); err != nil || string(b) != data {
+		t.Errorf("The loaded file changed: %q, %v", b, err)
+	}
+}
+
+// TestAtomicRollback checks that an atomic run that fails, at a statement
+// of its own or at a write to a second database, leaves the .duckdb files
+// as they were.
+func TestAtomicRollback(t *testing.T) {
+	bin := buildHiveDuck(t)
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	yaml := fmt.Sprintf("databases:\n  sales: %s\n  hr: %s\n",
+		filepath.Join(dir, "sales.duckdb"), filepath.Join(dir, "hr.duckdb"))
+	if err := os.WriteFile(config, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(script string, args ...string) (string, string, int) {
+		return runHiveDuck(t, bin, append([]string{"--config", config, "-f", filepath.Join("cli", script)}, args...)...)
+	}
+
+	if _, stderr, code := run("atomic_setup.sql"); code != 0 {
+		t.Fatalf("Setup failed with exit status %d\nStderr: %s", code, stderr)
+	}
+	for script, msg := range map[string]string{
+		"atomic_rollback.sql":      "Table not found 'missing'",
+		"atomic_two_databases.sql": "a single transaction can only write to a single attached database",
+	} {
+		_, stderr, code := run(script, "--atomic")
+		if code == 0 {
+			t.Errorf("%s: exit status 0, want a failure", script)
+		}
+		for _, m := range []string{"Rolled back all statements of the script", msg} {
+			if !strings.Contains(stderr, m) {
+				t.Errorf("%s: stderr does not contain %q:\n%s", script, m, stderr)
+			}
+		}
+	}
+
+	stdout, stderr, code := run("atomic_check.sql")
+	if code != 0 {
+		t.Fatalf("Check failed with exit status %d\nStderr: %s", code, stderr)
+	}
+	if actual, expected := strings.TrimSpace(stdout), "orders\n1\nstaff\n0"; actual != expected {
+		t.Errorf("Output mismatch:\n--- Expected ---\n%s\n--- Actual ---\n%s", expected, actual)
+	}
+}
+
+// hasLine reports whether s has a line equal to line.
+func hasLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+// TestHiveErrors checks that failed statements are reported as the Hive
+// CLI reports them, with the place in the script on the next line, and that
+// hive-duck exits with Hive's exit status.
+func TestHiveErrors(t *testing.T) {
+	bin := buildHiveDuck(t)
+	tests := []struct {
+		script string
+		lines  []string
+		code   int
+	}{
+		{"error_table.sql", []string{
+			"FAILED: SemanticException [Error 10001]: Line 1:14 Table not found 'orders'",
+			"  at cli/error_table.sql:2:15",
+		}, 17},
+		{"error_column.sql", []string{
+			"FAILED: SemanticException [Error 10004]: Line 2:7 Invalid table alias or column reference 'amt'",
+			"  at cli/error_column.sql:3:8",
+		}, 20},
+		{"error_parse.sql", []string{
+			`FAILED: ParseException syntax error at or near "t"`,
+			"  at cli/error_parse.sql:2:1",
+		}, 64},
+		{"error_conversion.sql", []string{
+			"FAILED: Execution Error, return code 2 from org.apache.hadoop.hive.ql.exec.mr.MapRedTask. Could not convert string 'abc' to INT32",
+			"  at cli/error_conversion.sql:2:8",
+		}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			_, stderr, code := runHiveDuck(t, bin, "-f", filepath.Join("cli", tt.script))
+			for _, line := range tt.lines {
+				if !hasLine(stderr, line) {
+					t.Errorf("Stderr has no line %q:\n%s", line, stderr)
+				}
+			}
+			if code != tt.code {
+				t.Errorf("Exit status %d, want %d\nStderr: %s", code, tt.code, stderr)
+			}
+		})
+	}
+}
+
+// TestIgnoreErrors checks that a script goes on after failed statements
+// when errors are ignored, lists the failures once it ran and exits with
+// the status of the last one.
+func TestIgnoreErrors(t *testing.T) {
+	bin := buildHiveDuck(t)
+	for _, args := range [][]string{
+		{"--ignore-errors"},
+		{"--hiveconf", "hive.cli.errors.ignore=true"},
+	} {
+		t.Run(args[len(args)-1], func(t *testing.T) {
+			stdout, stderr, code := runHiveDuck(t, bin, append([]string{"-f", filepath.Join("cli", "ignore_errors.sql")}, args...)...)
+
+			if actual, expected := strings.TrimSpace(stdout), "a\n1\nb\n2\nc\n3"; actual != expected {
+				t.Errorf("Output mismatch:\n--- Expected ---\n%s\n--- Actual ---\n%s", expected, actual)
+			}
+			summary := "2 statement(s) failed:\n" +
+				"  cli/ignore_errors.sql:3:15: FAILED: SemanticException [Error 10001]: Line 1:14 Table not found 'missing'\n" +
+				"  cli/ignore_errors.sql:5:8: FAILED: Execution Error, return code 2 from org.apache.hadoop.hive.ql.exec.mr.MapRedTask. Could not convert string 'x' to INT32\n"
+			if !strings.HasSuffix(stderr, summary) {
+				t.Errorf("Stderr does not end with the summary %q:\n%s", summary, stderr)
+			}
+			if code != 2 {
+				t.Errorf("Exit status %d, want 2\nStderr: %s", code, stderr)
+			}
+		})
+	}
+}
+
+// TestErrorPositions checks that an error is placed at its line and column
+// in the script as written: past comments, before variable substitution and
+// within a line of several statements.
+func TestErrorPositions(t *testing.T) {
+	bin := buildHiveDuck(t)
+	for script, at := range map[string]string{
+		"position_comment.sql":   "  at cli/position_comment.sql:4:22",
+		"position_variable.sql":  "  at cli/position_variable.sql:3:24",
+		"position_same_line.sql": "  at cli/position_same_line.sql:2:38",
+	} {
+		t.Run(script, func(t *testing.T) {
+			_, stderr, code := runHiveDuck(t, bin, "-f", filepath.Join("cli", script))
+			if !hasLine(stderr, at) {
+				t.Errorf("Stderr has no line %q:\n%s", at, stderr)
+			}
+			if code != 20 {
+				t.Errorf("Exit status %d, want 20\nStderr: %s", code, stderr)
+			}
+		})
+	}
+}
+
+// timeTaken matches the time of a Time taken line, which changes from run
+// to run.
+var timeTaken = regexp.MustCompile(`(?m)^Time taken: [0-9.]+ seconds`)
+
+// statusReport returns the report of a run on stderr with the times masked
+// and without the lines of the allocator, which some systems print.
+func statusReport(stderr string) string {
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		if !strings.HasPrefix(l, "<jemalloc>") {
+			lines = append(lines, l)
+		}
+	}
+	return timeTaken.ReplaceAllString(strings.Join(lines, "\n"), "Time taken: <time> seconds")
+}
+
+// TestStatus checks the report of each statement on stderr, which -S turns
+// off, and the statements -v and -vv echo with it.
+func TestStatus(t *testing.T) {
+	bin := buildHiveDuck(t)
+	tests := []struct {
+		flag   string
+		report string
+	}{
+		{"", `OK
+Time taken: <time> seconds
+Loaded 2 row(s)
+OK
+Time taken: <time> seconds
+OK
+Time taken: <time> seconds, Fetched: 2 row(s)`},
+		{"-S", ""},
+		{"-v", `SET hivevar:n=2
+CREATE TABLE t (id INT)
+OK
+Time taken: <time> seconds
+INSERT INTO t VALUES (1), (2)
+Loaded 2 row(s)
+OK
+Time taken: <time> seconds
+SELECT id FROM t ORDER BY id
+OK
+Time taken: <time> seconds, Fetched: 2 row(s)`},
+		{"-vv", `SET hivevar:n=2
+  -> -- hive-duck: SET hivevar:n=2
+CREATE TABLE t (id INT)
+  -> CREATE TABLE t (id INTEGER)
+  -> -- hive-duck: register managed_table t
+OK
+Time taken: <time> seconds
+INSERT INTO t VALUES (1), (2)
+  -> -- hive-duck: INSERT INTO TABLE t VALUES (1), (2)
+Loaded 2 row(s)
+OK
+Time taken: <time> seconds
+SELECT id FROM t ORDER BY id
+  -> SELECT id FROM t ORDER BY id
+OK
+Time taken: <time> seconds, Fetched: 2 row(s)`},
+	}
+	for _, tt := range tests {
+		t.Run("flag"+tt.flag, func(t *testing.T) {
+			args := []string{"-f", filepath.Join("cli", "status.sql")}
+			if tt.flag != "" {
+				args = append(args, tt.flag)
+			}
+			stdout, stderr, code := runHiveDuck(t, bin, args...)
+			if code != 0 {
+				t.Fatalf("Exit status %d\nStderr: %s", code, stderr)
+			}
+			if actual, expected := strings.TrimSpace(stdout), "id\n1\n2"; actual != expected {
+				t.Errorf("Output mismatch:\n--- Expected ---\n%s\n--- Actual ---\n%s", expected, actual)
+			}
+			if actual := statusReport(stderr); actual != tt.report {
+				t.Errorf("Report mismatch:\n--- Expected ---\n%s\n--- Actual ---\n%s\n--- Diff ---\n%s",
+					tt.report, actual, diffStrings(tt.report, actual))
+			}
+		})
+	}
+}
+
+// TestCatalogPersistence checks that the metadata of a table one run
+// creates in a .duckdb file, its properties, partitions and comments, is
+// read back by a later run and listed by the catalog subcommand.
+func TestCatalogPersistence(t *testing.T) {
+	bin := buildHiveDuck(t)
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	yaml := fmt.Sprintf("databases:\n  sales: %s\n", filepath.Join(dir, "sales.duckdb"))
+	if err := os.WriteFile(config, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, stderr, code := runHiveDuck(t, bin, "--config", config, "-S", "-f", filepath.Join("cli", "catalog_create.sql")); code != 0 {
+		t.Fatalf("Create failed with exit status %d\nStderr: %s", code, stderr)
+	}
+
+	stdout, stderr, code := runHiveDuck(t, bin, "--config", config, "-S", "-f", filepath.Join("cli", "catalog_read.sql"))
+	if code != 0 {
+		t.Fatalf("Read failed with exit status %d\nStderr: %s", code, stderr)
+	}
+	for _, line := range []string{
+		"owner.team  billing",
+		"comment    customer orders",
+		"dt=2024-01-01",
+		"dt=2024-01-02",
+		"id                       int            order id",
+		"dt                       string         order date",
+	} {
+		if !hasLine(stdout, line) {
+			t.Errorf("Stdout has no line %q:\n%s", line, stdout)
+		}
+	}
+
+	stdout, stderr, code = runHiveDuck(t, bin, "catalog", "--config", config, "sales")
+	if code != 0 {
+		t.Fatalf("catalog failed with exit status %d\nStderr: %s", code, stderr)
+	}
+	if line := "sales     orders  MANAGED_TABLE  TEXTFILE            dt              1"; !hasLine(stdout, line) {
+		t.Errorf("catalog does not list %q:\n%s", line, stdout)
+	}
+
+	stdout, stderr, code = runHiveDuck(t, bin, "catalog", "--config", config, "sales.orders")
+	if code != 0 {
+		t.Fatalf("catalog sales.orders failed with exit status %d\nStderr: %s", code, stderr)
+	}
+	var table catalog.Table
+	if err := json.Unmarshal([]byte(stdout), &table); err != nil {
+		t.Fatalf("catalog sales.orders printed no table: %v\n%s", err, stdout)
+	}
+	if table.Comment != "customer orders" || table.Properties["owner.team"] != "billing" ||
+		len(table.Columns) != 2 || table.Columns[0].Comment != "order id" ||
+		len(table.PartitionKeys) != 1 || table.PartitionKeys[0].Comment != "order date" ||
+		len(table.Partitions) != 1 || table.Partitions[0].Values[0] != "2024-01-02" {
+		t.Errorf("catalog sales.orders printed the wrong entry:\n%s", stdout)
+	}
+}
@@ -0,0 +1,49 @@
+-- ALTER TABLE Test
+-- Hive column and table operations on native and file-backed tables
+
+CREATE TABLE employees (id INT, name STRING, salary INT)
+PARTITIONED BY (ds STRING);
+
+INSERT INTO employees VALUES (1, 'ann', 100, '2025-01-01'), (2, 'bob', 200, '2025-01-02');
+
+-- Rename and widen in place, keeping the data
+ALTER TABLE employees CHANGE COLUMN salary pay BIGINT COMMENT 'monthly pay';
+
+-- Reordering rebuilds the table; partition columns stay last
+ALTER TABLE employees CHANGE pay pay BIGINT AFTER id;
+
+ALTER TABLE employees ADD COLUMNS (dept STRING COMMENT 'department');
+
+DESCRIBE employees;
+
+SELECT * FROM employees ORDER BY id;
+
+-- Columns that keep their name keep their data
+ALTER TABLE employees REPLACE COLUMNS (id INT, name STRING, dept STRING);
+
+ALTER TABLE employees RENAME TO staff;
+
+ALTER TABLE staff SET TBLPROPERTIES ('comment'='all staff', 'owner.team'='hr');
+
+ALTER TABLE staff SET FILEFORMAT PARQUET;
+
+SHOW TABLES;
+
+SHOW CREATE TABLE staff;
+
+SELECT * FROM staff ORDER BY id;
+
+-- File-backed tables are schema-on-read: new columns read as NULL
+CREATE EXTERNAL TABLE people (id INT, name STRING)
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+LOCATION 'golden/alter_table/data/people';
+
+ALTER TABLE people ADD COLUMNS (email STRING);
+
+ALTER TABLE people CHANGE COLUMN name full_name STRING;
+
+SELECT * FROM people ORDER BY id;
+
+ALTER TABLE people SET SERDEPROPERTIES ('field.delim'='|');
+
+SELECT * FROM people ORDER BY id;
@@ -0,0 +1,14 @@
+-- Atomic run: the script runs in one transaction, committed at its end
+-- DuckDB's own temporary tables stage data for the permanent ones
+
+CREATE TEMP TABLE staged_orders AS
+SELECT * FROM (VALUES (1, 'ann', 20.0), (2, 'bob', 35.5), (3, 'ann', 12.5)) AS v(id, customer, amount);
+
+CREATE TABLE orders (id INT, customer STRING, amount DOUBLE);
+
+INSERT INTO orders SELECT * FROM staged_orders;
+
+CREATE TABLE customer_totals AS
+SELECT customer, sum(amount) AS total FROM orders GROUP BY customer;
+
+SELECT * FROM customer_totals ORDER BY customer;
@@ -0,0 +1,10 @@
+-- Atomic run with a warehouse database: the discovered tables are only read,
+-- so the transaction writes a single database
+
+SHOW DATABASES;
+
+SHOW TABLES IN sales;
+
+CREATE TABLE sales.customer_count AS SELECT count(*) AS customers FROM sales.customers;
+
+SELECT * FROM sales.customer_count;
@@ -0,0 +1,18 @@
+-- Hive metadata is stored in the _hive_duck_meta schema and kept across runs
+CREATE TABLE events (id INT, kind STRING)
+PARTITIONED BY (ds STRING)
+STORED AS PARQUET
+TBLPROPERTIES ('owner.team'='growth');
+
+ALTER TABLE events ADD PARTITION (ds='2025-01-15') PARTITION (ds='2025-01-16');
+
+CREATE TABLE scratch (id INT);
+DROP TABLE scratch;
+
+SELECT table_name,
+       definition->>'$.type' AS type,
+       definition->>'$.storage.format' AS format,
+       json_array_length(definition->'$.partitions') AS partitions,
+       definition->>'$.properties."owner.team"' AS team
+FROM _hive_duck_meta.tables
+ORDER BY table_name;
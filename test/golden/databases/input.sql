@@ -0,0 +1,19 @@
+-- CREATE/DROP DATABASE attach and detach DuckDB databases
+CREATE DATABASE IF NOT EXISTS sales COMMENT 'Sales data' WITH DBPROPERTIES ('owner.team'='revenue');
+CREATE DATABASE IF NOT EXISTS sales;
+CREATE SCHEMA staging;
+SHOW DATABASES;
+
+USE sales;
+CREATE TABLE orders (id INT, amount DOUBLE);
+INSERT INTO orders VALUES (1, 9.5), (2, 20.0);
+ALTER DATABASE sales SET DBPROPERTIES ('retention'='30d');
+
+USE analytics;
+SELECT count(*) AS orders, sum(amount) AS total FROM sales.orders;
+SHOW TABLES IN sales;
+
+DROP DATABASE staging;
+DROP DATABASE sales CASCADE;
+DROP DATABASE IF EXISTS sales;
+SHOW DATABASES;
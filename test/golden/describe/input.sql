@@ -0,0 +1,48 @@
+-- DESCRIBE and SHOW TBLPROPERTIES Test
+-- Hive-layout table, column and property metadata from the local catalog
+
+CREATE TABLE users (
+    id INT COMMENT 'user id',
+    name STRING,
+    tags ARRAY<STRING>,
+    address STRUCT<city:STRING, zip:INT>
+)
+COMMENT 'registered users'
+PARTITIONED BY (ds STRING)
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+TBLPROPERTIES ('owner.team'='growth');
+
+INSERT INTO users VALUES
+    (1, 'ann', ['a', 'b'], {'city': 'Oslo', 'zip': 150}, '2025-01-01'),
+    (2, NULL, [], NULL, '2025-01-01'),
+    (3, 'bob', ['c'], {'city': 'Rome', 'zip': 100}, '2025-01-02');
+
+DESCRIBE users;
+
+DESCRIBE users.name;
+
+DESCRIBE FORMATTED users id;
+
+DESCRIBE FORMATTED users name;
+
+SHOW TBLPROPERTIES users('owner.team');
+
+SHOW TBLPROPERTIES users('comment');
+
+SHOW TBLPROPERTIES users('missing');
+
+CREATE VIEW user_names AS SELECT id, upper(name) AS name FROM users;
+
+DESCRIBE user_names;
+
+-- Table-level detail; the owner is the user.name system property
+SET system:user.name=hive;
+
+CREATE TABLE events (id INTEGER COMMENT 'event id', kind VARCHAR, amount NUMERIC(8, 2))
+PARTITIONED BY (ds VARCHAR);
+
+DESCRIBE events;
+
+DESCRIBE FORMATTED events;
+
+DESCRIBE EXTENDED events;
@@ -0,0 +1,15 @@
+-- DESCRIBE in the hive format
+-- The blank cells of DESCRIBE output print empty, as in the Hive CLI, while
+-- nulls of query results print NULL
+
+SET system:user.name=hive;
+
+CREATE TABLE events (id INT COMMENT 'event id', name STRING)
+COMMENT 'site events'
+PARTITIONED BY (dt STRING COMMENT 'event date');
+
+DESCRIBE events;
+
+DESCRIBE FORMATTED events;
+
+SELECT NULL AS missing, 1 AS present;
@@ -0,0 +1,38 @@
+-- EXPORT/IMPORT Test
+-- EXPORT TABLE writes Parquet files plus a _metadata file; IMPORT TABLE
+-- recreates the table, optionally renamed or limited to some partitions
+
+CREATE TABLE sales (id INT, amount DOUBLE COMMENT 'in USD')
+COMMENT 'daily sales'
+PARTITIONED BY (ds STRING)
+STORED AS ORC
+TBLPROPERTIES ('owner'='etl');
+
+INSERT INTO sales PARTITION (ds='2025-01-01') VALUES (1, 10.5), (2, 3.0);
+INSERT INTO sales PARTITION (ds='2025-01-02') VALUES (3, 7.0);
+
+CREATE TABLE regions (code STRING, name STRING);
+INSERT INTO regions VALUES ('EU', 'Europe'), ('NA', 'North America');
+
+EXPORT TABLE sales TO 'golden/export_import/export/sales';
+EXPORT TABLE regions TO 'golden/export_import/export/regions';
+
+DROP TABLE regions;
+
+IMPORT FROM 'golden/export_import/export/regions';
+
+SELECT * FROM regions ORDER BY code;
+
+IMPORT TABLE sales_copy FROM 'golden/export_import/export/sales';
+
+SHOW PARTITIONS sales_copy;
+
+DESCRIBE sales_copy;
+
+SHOW TBLPROPERTIES sales_copy('owner');
+
+SELECT * FROM sales_copy ORDER BY id;
+
+IMPORT TABLE sales_0102 PARTITION (ds='2025-01-02') FROM 'golden/export_import/export/sales';
+
+SELECT * FROM sales_0102 ORDER BY id;
@@ -0,0 +1,29 @@
+-- Hive Output Format Test
+-- The hive format prints rows as the Hive CLI does, with a header only if
+-- hive.cli.print.header is set
+
+CREATE TABLE orders (id INT, Status STRING, amount DOUBLE, order_date DATE);
+
+INSERT INTO orders VALUES
+    (1, 'open', 10.0, DATE '2025-01-15'),
+    (2, NULL, 12.5, DATE '2025-01-16'),
+    (3, 'closed', 12000000.0, NULL);
+
+SELECT * FROM orders ORDER BY id;
+
+SET hive.cli.print.header=true;
+
+-- Columns read from a table are prefixed with its alias
+SELECT * FROM orders o WHERE id = 1;
+SELECT id, status AS state, amount * 2, upper(status) FROM orders ORDER BY id;
+SELECT o.id, p.amount FROM orders o JOIN orders p ON o.id = p.id ORDER BY o.id;
+
+-- Grouped results are not attributed to a table
+SELECT status, count(*) FROM orders GROUP BY status ORDER BY status;
+
+SELECT [1, 2] AS ids, MAP {'k': 'v'} AS props, 1.50::DECIMAL(5,2) AS price, true AS flag;
+
+SHOW TABLES;
+
+SET hive.resultset.use.unique.column.names=false;
+SELECT * FROM orders WHERE id = 2;
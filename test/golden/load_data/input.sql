@@ -0,0 +1,29 @@
+-- LOAD DATA Test
+-- LOAD DATA LOCAL INPATH using the delimiters declared in CREATE TABLE
+
+CREATE TABLE users (
+    id INT COMMENT 'user id',
+    name STRING,
+    tier STRING
+)
+PARTITIONED BY (ds STRING)
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+STORED AS TEXTFILE;
+
+CREATE TABLE users_quoted (id STRING, name STRING, tier STRING)
+ROW FORMAT SERDE 'org.apache.hadoop.hive.serde2.OpenCSVSerde'
+WITH SERDEPROPERTIES ('separatorChar' = '|', 'quoteChar' = '"')
+STORED AS TEXTFILE
+TBLPROPERTIES ('skip.header.line.count' = '1');
+
+LOAD DATA LOCAL INPATH 'golden/load_data/users.csv' INTO TABLE users PARTITION (ds='2025-01-14');
+LOAD DATA LOCAL INPATH 'golden/load_data/users.csv' INTO TABLE users PARTITION (ds='2025-01-15');
+LOAD DATA LOCAL INPATH 'golden/load_data/users.csv' OVERWRITE INTO TABLE users PARTITION (ds='2025-01-15');
+LOAD DATA LOCAL INPATH 'golden/load_data/users_quoted.csv' INTO TABLE users_quoted;
+
+SELECT ds, COUNT(*) AS users, COUNT(tier) AS with_tier
+FROM users
+GROUP BY ds
+ORDER BY ds;
+
+SELECT * FROM users_quoted;
@@ -0,0 +1,41 @@
+-- Materialized View Test
+-- Materialized views hold their query's result until REBUILD re-runs the
+-- stored query
+
+CREATE TABLE sales (id INT, region STRING, amount DOUBLE);
+
+INSERT INTO sales VALUES (1, 'east', 10.0), (2, 'west', 20.0), (3, 'east', 5.0);
+
+CREATE MATERIALIZED VIEW region_totals
+DISABLE REWRITE
+COMMENT 'totals per region'
+PARTITIONED ON (region)
+STORED AS ORC
+AS SELECT region, sum(amount) AS total, count(*) AS orders FROM sales GROUP BY region;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS region_totals AS SELECT 1;
+
+SHOW MATERIALIZED VIEWS;
+
+DESCRIBE region_totals;
+
+SELECT * FROM region_totals ORDER BY region;
+
+-- New rows are not visible until the view is rebuilt
+INSERT INTO sales VALUES (4, 'north', 7.5), (5, 'west', 2.5);
+
+SELECT * FROM region_totals ORDER BY region;
+
+ALTER MATERIALIZED VIEW region_totals REBUILD;
+
+SELECT * FROM region_totals ORDER BY region;
+
+ALTER MATERIALIZED VIEW region_totals ENABLE REWRITE;
+
+DROP MATERIALIZED VIEW region_totals;
+
+DROP MATERIALIZED VIEW IF EXISTS region_totals;
+
+SHOW MATERIALIZED VIEWS;
+
+SHOW TABLES;
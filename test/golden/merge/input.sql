@@ -0,0 +1,45 @@
+-- MERGE Test
+-- MERGE INTO runs as DELETE, UPDATE and INSERT statements in one
+-- transaction, with every clause seeing the target as it was before
+
+CREATE TABLE dim_customer (id INT, name STRING, city STRING, version INT);
+
+INSERT INTO dim_customer VALUES
+    (1, 'Ann', 'Oslo', 1),
+    (2, 'Bob', 'Rome', 1),
+    (3, 'Cid', 'Lima', 1);
+
+CREATE TABLE customer_updates (id INT, name STRING, city STRING, op STRING);
+
+INSERT INTO customer_updates VALUES
+    (1, 'Ann', 'Bergen', 'U'),
+    (2, 'Bob', NULL, 'D'),
+    (4, 'Dee', 'Kyiv', 'U');
+
+MERGE INTO dim_customer AS t
+USING (SELECT * FROM customer_updates WHERE op IN ('U', 'D')) AS s
+ON t.id = s.id
+WHEN MATCHED AND s.op = 'D' THEN DELETE
+WHEN MATCHED THEN UPDATE SET city = s.city, version = t.version + 1
+WHEN NOT MATCHED THEN INSERT VALUES (s.id, s.name, s.city, 1);
+
+SELECT * FROM dim_customer ORDER BY id;
+
+-- Without aliases, columns are qualified by the table names
+MERGE INTO dim_customer
+USING customer_updates
+ON dim_customer.id = customer_updates.id
+WHEN NOT MATCHED THEN INSERT (id, name) VALUES (customer_updates.id, upper(customer_updates.name));
+
+SELECT * FROM dim_customer ORDER BY id;
+
+-- Temporary tables as the source and in clause conditions
+CREATE TEMPORARY TABLE tmp_src AS SELECT 10 AS id, 'kim' AS name, 'Busan' AS city;
+CREATE TEMPORARY TABLE tmp_blocked AS SELECT 'Seoul' AS city;
+
+MERGE INTO dim_customer t
+USING tmp_src s
+ON t.id = s.id
+WHEN NOT MATCHED AND s.city NOT IN (SELECT city FROM tmp_blocked) THEN INSERT VALUES (s.id, s.name, s.city, 1);
+
+SELECT * FROM dim_customer ORDER BY id;
@@ -0,0 +1,38 @@
+-- Partition Management Test
+-- MSCK REPAIR, ADD/DROP PARTITION and SHOW PARTITIONS against a local
+-- partition registry, for both file-backed and native tables
+
+CREATE EXTERNAL TABLE events (event_id INT, event_type STRING)
+PARTITIONED BY (ds STRING)
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+LOCATION 'golden/partitions/data/events';
+
+MSCK REPAIR TABLE events;
+
+ALTER TABLE events ADD IF NOT EXISTS PARTITION (ds='2025-01-16') LOCATION 'golden/partitions/data/late';
+
+SHOW PARTITIONS events;
+
+ALTER TABLE events DROP PARTITION (ds<'2025-01-15');
+
+SELECT ds, event_type, COUNT(*) AS events
+FROM events
+GROUP BY ds, event_type
+ORDER BY ds, event_type;
+
+CREATE TABLE daily_counts (event_type STRING, cnt BIGINT)
+PARTITIONED BY (ds STRING, hr INT);
+
+INSERT INTO daily_counts VALUES
+    ('click', 1, '2025-01-14', 9),
+    ('view', 1, '2025-01-14', 10),
+    ('view', 1, '2025-01-15', 9);
+
+ALTER TABLE daily_counts ADD PARTITION (ds='2025-01-16', hr=0);
+ALTER TABLE daily_counts DROP PARTITION (ds='2025-01-15');
+
+-- A truncated partition stays, with no rows
+TRUNCATE TABLE daily_counts PARTITION (ds='2025-01-14', hr=10);
+
+SHOW PARTITIONS daily_counts;
+SELECT ds, hr, count(*) AS cnt FROM daily_counts GROUP BY ds, hr ORDER BY ds, hr;
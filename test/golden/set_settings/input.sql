@@ -0,0 +1,22 @@
+-- SET Settings Test
+-- Hive and MapReduce conf keys with a local meaning become DuckDB settings
+
+SET mapreduce.map.memory.mb=2048;
+SET hive.exec.parallel.thread.number=2;
+SET hive.exec.scratchdir=/tmp/hive-duck-scratch;
+
+SELECT
+    current_setting('memory_limit') AS memory_limit,
+    current_setting('threads') AS threads,
+    current_setting('temp_directory') AS temp_directory;
+
+-- Other keys are kept for substitution only
+SET mapred.job.queue.name=etl;
+SELECT '${hiveconf:mapred.job.queue.name}' AS queue;
+
+SET hive.tez.container.size=512;
+SELECT current_setting('memory_limit') AS memory_limit;
+
+-- Codecs without a Parquet equivalent are ignored
+SET hive.exec.orc.default.compress=LZO;
+SET hive.exec.orc.default.compress;
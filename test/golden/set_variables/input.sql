@@ -0,0 +1,25 @@
+-- SET Variables Test
+-- Values set in the script are substituted in the statements after them
+
+SET hivevar:ds=2025-01-15;
+SET run_date='${hivevar:ds}';
+SET hiveconf:job.name=daily_${hivevar:ds};
+
+SELECT '${hivevar:ds}' AS ds, '${hiveconf:run_date}' AS run_date, '${hiveconf:job.name}' AS job;
+
+SET job.name;
+SET hivevar:ds;
+SET system:etl.owner=reporting;
+SELECT '${system:etl.owner}' AS owner;
+
+-- Later SETs replace earlier values
+SET hivevar:ds=2025-01-16;
+SELECT '${hivevar:ds}' AS ds;
+
+-- RESET restores the conf values from the command line and keeps variables
+SET batch.size=500;
+SET batch.size;
+RESET;
+SET batch.size;
+SET run_date;
+SET hivevar:ds;
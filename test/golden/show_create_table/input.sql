@@ -0,0 +1,40 @@
+-- SHOW CREATE TABLE Test
+-- Hive DDL reconstructed from DuckDB schemas and preserved table metadata
+
+CREATE TABLE orders (
+    order_id BIGINT COMMENT 'order''s id',
+    items ARRAY<STRUCT<sku:STRING, qty:INT>>,
+    attributes MAP<STRING, STRING>
+)
+COMMENT 'customer orders'
+PARTITIONED BY (ds STRING, region STRING)
+CLUSTERED BY (order_id) SORTED BY (order_id DESC) INTO 8 BUCKETS
+ROW FORMAT DELIMITED
+    FIELDS TERMINATED BY '\001'
+    COLLECTION ITEMS TERMINATED BY '\002'
+    MAP KEYS TERMINATED BY '\003'
+STORED AS TEXTFILE
+TBLPROPERTIES ('owner.team'='checkout', 'retention.days'='30');
+
+SHOW CREATE TABLE orders;
+
+CREATE EXTERNAL TABLE clicks (user_id INT, url STRING)
+STORED AS PARQUET
+LOCATION 'golden/show_create_table/data/clicks';
+
+SHOW CREATE TABLE clicks;
+
+CREATE TABLE daily AS
+SELECT DATE '2025-01-01' AS day, 'web' AS channel, 1.5 AS score, [1, 2] AS ids;
+
+SHOW CREATE TABLE daily;
+
+CREATE VIEW web_daily AS SELECT day, score FROM daily WHERE channel = 'web';
+
+SHOW CREATE TABLE web_daily;
+
+-- DuckDB type names are shown as Hive's
+CREATE TABLE duck_typed (id INTEGER, name VARCHAR, code VARCHAR(8), price NUMERIC, ratio DOUBLE PRECISION, tags ARRAY<VARCHAR>)
+PARTITIONED BY (ds VARCHAR);
+
+SHOW CREATE TABLE duck_typed;
@@ -0,0 +1,33 @@
+-- Metadata Commands Test
+-- Hive-shaped SHOW DATABASES/TABLES/VIEWS/COLUMNS/FUNCTIONS and
+-- DESCRIBE FUNCTION, with Hive's 'a*|b*' LIKE patterns
+
+CREATE SCHEMA sales;
+
+SHOW DATABASES;
+
+SHOW SCHEMAS LIKE 'sal*';
+
+CREATE TABLE fact_orders (order_id INT, amount DOUBLE) PARTITIONED BY (ds STRING);
+CREATE TABLE dim_customers (customer_id INT, name STRING);
+CREATE TABLE audit_log (msg STRING);
+CREATE VIEW big_orders AS SELECT * FROM fact_orders WHERE amount > 100;
+CREATE TABLE sales.fact_returns (order_id INT);
+
+SHOW TABLES;
+
+SHOW TABLES LIKE 'fact_*|dim_*';
+
+SHOW TABLES IN sales 'fact*';
+
+SHOW VIEWS;
+
+SHOW COLUMNS IN fact_orders;
+
+SHOW COLUMNS FROM fact_returns IN sales;
+
+SHOW FUNCTIONS LIKE 'regexp_extract*';
+
+DESCRIBE FUNCTION sum;
+
+DESCRIBE FUNCTION no_such_function;
@@ -0,0 +1,48 @@
+-- Temporary Tables Test
+-- Temporary tables last for the session and take precedence over a
+-- permanent table of the same name in their database
+
+CREATE DATABASE stage;
+CREATE TABLE stage.orders (id INT, status STRING);
+
+INSERT INTO stage.orders VALUES (1, 'open'), (2, 'closed'), (3, 'open');
+
+USE stage;
+
+-- The temporary table is built from the permanent one, then hides it
+CREATE TEMPORARY TABLE orders AS
+SELECT id, upper(status) AS status FROM orders WHERE status = 'open';
+
+SELECT * FROM orders ORDER BY id;
+-- As in Hive, the qualified name also refers to the temporary table
+SELECT count(*) AS n FROM stage.orders;
+
+CREATE TEMPORARY TABLE scratch (id INT, note STRING);
+INSERT INTO scratch VALUES (10, 'first');
+INSERT INTO TABLE scratch SELECT id, status FROM orders;
+
+SELECT s.id, s.note, o.status
+FROM scratch s
+LEFT JOIN orders o ON s.id = o.id
+ORDER BY s.id;
+
+SHOW TABLES;
+DESCRIBE scratch;
+
+-- Dropping the temporary table uncovers the permanent one
+DROP TABLE orders;
+SELECT * FROM orders ORDER BY id;
+
+-- Names after the FROM of function arguments, and CTE names, are not
+-- references to temporary tables
+CREATE TEMPORARY TABLE ds AS SELECT 1 AS x;
+CREATE TEMPORARY TABLE recent AS SELECT DATE '2025-03-01' AS d;
+
+SELECT extract(year FROM ds) AS y, trim(BOTH 'x' FROM s) AS t, substring(s FROM 2) AS sub
+FROM (SELECT DATE '2024-05-06' AS ds, 'xabx' AS s) q;
+
+WITH recent AS (SELECT DATE '2020-01-01' AS d) SELECT * FROM recent;
+
+SELECT * FROM recent;
+
+SELECT (SELECT max(x) FROM ds) AS m, EXISTS (SELECT 1 FROM ds) AS e;
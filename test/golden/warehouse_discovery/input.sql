@@ -0,0 +1,9 @@
+SHOW DATABASES;
+SHOW TABLES IN sales;
+SHOW TABLES IN prod;
+SELECT * FROM sales.orders ORDER BY _c0;
+SHOW PARTITIONS sales.orders;
+SELECT * FROM sales.customers ORDER BY id;
+SELECT * FROM sales.events ORDER BY id;
+SELECT * FROM prod.regions ORDER BY _c0;
+DESCRIBE sales.orders;
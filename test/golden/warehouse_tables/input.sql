@@ -0,0 +1,27 @@
+-- Managed PARQUET tables kept as files under warehouse/sales.db/
+CREATE TABLE staging (id INT, name STRING, ds STRING);
+INSERT INTO staging VALUES (1, 'a', '2024-01-01'), (2, 'b', '2024-01-01'), (3, 'c', '2024-01-02');
+
+CREATE TABLE events (id INT, name STRING) PARTITIONED BY (ds STRING) STORED AS PARQUET;
+INSERT INTO TABLE events PARTITION (ds) SELECT id, name, ds FROM staging;
+SELECT * FROM events ORDER BY id;
+SHOW PARTITIONS events;
+
+-- Only the written partition is replaced
+INSERT OVERWRITE TABLE events PARTITION (ds='2024-01-01') SELECT id * 10, name FROM staging WHERE id = 1;
+SELECT * FROM events ORDER BY id;
+SELECT count(*) AS files FROM glob('golden/warehouse_tables/warehouse/sales.db/events/*/*');
+
+CREATE TABLE names STORED AS PARQUET AS SELECT id, upper(name) AS name FROM staging;
+INSERT INTO names (id) VALUES (4);
+SELECT * FROM names ORDER BY id;
+INSERT OVERWRITE TABLE names SELECT id, name FROM names WHERE id > 2;
+SELECT * FROM read_parquet('golden/warehouse_tables/warehouse/sales.db/names/*') ORDER BY id;
+
+-- DuckDB tables get the same INSERT OVERWRITE semantics
+INSERT OVERWRITE TABLE staging SELECT * FROM staging WHERE id < 3;
+SELECT count(*) AS n FROM staging;
+
+DROP TABLE events;
+DROP TABLE names;
+SELECT count(*) AS files FROM glob('golden/warehouse_tables/warehouse/sales.db/**');
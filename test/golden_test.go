@@ -2,9 +2,11 @@ package test
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -79,7 +81,7 @@ func runGoldenTest(t *testing.T, testDir string) {
 	}
 
 	// Compare output
-	actual := strings.TrimSpace(stdout.String())
+	actual := maskVolatile(strings.TrimSpace(stdout.String()))
 
 	if actual != expected {
 		t.Errorf("Output mismatch:\n--- Expected ---\n%s\n--- Actual ---\n%s\n--- Diff ---\n%s",
@@ -87,6 +89,24 @@ func runGoldenTest(t *testing.T, testDir string) {
 	}
 }
 
+var (
+	// Creation times of tables, as DESCRIBE FORMATTED and EXTENDED show them.
+	createTimeRow = regexp.MustCompile(`(?m)^(CreateTime:\s+)(\w{3} \w{3} .*?\d{4})([ \t]+)`)
+	epochTime     = regexp.MustCompile(`(transient_lastDdlTime\s+|transient_lastDdlTime=|createTime:)[1-9]\d{9}\b`)
+)
+
+// maskVolatile replaces the times tables were created at, which change from
+// run to run, with placeholders of the same width, so that columns stay
+// aligned.
+func maskVolatile(s string) string {
+	s = createTimeRow.ReplaceAllStringFunc(s, func(m string) string {
+		sub := createTimeRow.FindStringSubmatch(m)
+		width := len(sub[2]) + len(sub[3])
+		return sub[1] + fmt.Sprintf("%-*s", width, "<create time>")
+	})
+	return epochTime.ReplaceAllString(s, "${1}<unixtime>")
+}
+
 // parseArgs parses space/newline-separated arguments from a string
 func parseArgs(s string) []string {
 	var args []string
@@ -0,0 +1,15 @@
+-- Hive DDL as SHOW CREATE TABLE prints it, for the default database
+CREATE TABLE `default`.`orders`(
+  `order_id` bigint,
+  `amount` decimal(10,2))
+PARTITIONED BY (
+  `ds` string)
+STORED AS ORC;
+
+CREATE TABLE IF NOT EXISTS default.orders (order_id BIGINT);
+
+CREATE VIEW big_orders AS SELECT order_id FROM orders WHERE amount > 100;
+
+ANALYZE TABLE orders COMPUTE STATISTICS;
+
+CREATE TABLE sales.customers (id INT, name STRING);